@@ -0,0 +1,500 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/stefanprifti/gqlclientgen"
+	"github.com/stefanprifti/gqlclientgen/schemadiff"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// exit codes of the CLI
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+const usage = `gqlclientgen generates Go clients for GraphQL services.
+
+Usage:
+  gqlclientgen [flags] [command] [flags]
+
+Commands:
+  generate    introspect the schemas and generate the clients (default)
+  schema pull fetch the schemas and write the schema files, refreshing the cache
+  introspect  same as schema pull
+  validate    validate the operations against the schemas, without writing files
+  check       fail and print a diff when the generated files are out of date, without writing files
+  watch       generate the clients, then regenerate a client when its operation files change
+  init        write a sample config file
+
+Flags:
+`
+
+const sampleConfig = `version: 1
+
+services:
+  - name: Countries API
+    package: countries
+    url: https://countries.trevorblades.com/graphql
+    operations:
+      root: ./gql/countries
+    client:
+      root: ./pkg/countries
+`
+
+// command is a subcommand of the CLI, run for every selected service
+type command struct {
+	name string
+	run  func(ctx context.Context, s *gqlclientgen.Service, log *logger) error
+}
+
+var commands = []command{
+	{name: "generate", run: generateService},
+	{name: "schema pull", run: pullSchema},
+	{name: "introspect", run: pullSchema},
+	{name: "validate", run: validateService},
+	{name: "check", run: checkService},
+}
+
+// options are the flags shared by the commands
+type options struct {
+	config   string
+	services stringList
+	verbose  bool
+	quiet    bool
+	interval time.Duration
+}
+
+// stringList is a flag which can be repeated or hold comma separated values
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+
+	return nil
+}
+
+// runCLI runs the CLI with the arguments, without the program name, and
+// returns the exit code
+func runCLI(args []string, stdout, stderr io.Writer) int {
+	var opts options
+	flags := flag.NewFlagSet("gqlclientgen", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&opts.config, "config", gqlclientgen.ConfigFileName, "path of the config `file`")
+	flags.Var(&opts.services, "service", "only process the service with this name or package, can be repeated")
+	flags.BoolVar(&opts.verbose, "verbose", false, "print the steps of the generation")
+	flags.BoolVar(&opts.quiet, "quiet", false, "only print the errors")
+	flags.DurationVar(&opts.interval, "interval", defaultWatchInterval, "how often watch checks the operation files")
+
+	// the flags may come before the command, after it, or both
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+
+		return exitUsage
+	}
+	args = flags.Args()
+
+	name := "generate"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	// schema has its own subcommands
+	if name == "schema" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = name+" "+args[0], args[1:]
+	}
+
+	if name == "help" {
+		flags.Usage()
+		return exitOK
+	}
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+
+		return exitUsage
+	}
+
+	if flags.NArg() > 0 {
+		fmt.Fprintf(stderr, "unexpected arguments: %s\n", strings.Join(flags.Args(), " "))
+		return exitUsage
+	}
+
+	if opts.verbose && opts.quiet {
+		fmt.Fprintln(stderr, "--verbose and --quiet can't be used together")
+		return exitUsage
+	}
+
+	if opts.interval <= 0 {
+		fmt.Fprintln(stderr, "--interval must be positive")
+		return exitUsage
+	}
+
+	log := newLogger(stdout, opts)
+
+	if name == "init" {
+		if err := initConfig(opts.config); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+
+		log.Infof("wrote %s", opts.config)
+		return exitOK
+	}
+
+	cmd, ok := findCommand(name)
+	if !ok && name != "watch" {
+		fmt.Fprintf(stderr, "unknown command %q\n\n", name)
+		flags.Usage()
+		return exitUsage
+	}
+
+	config, err := gqlclientgen.LoadConfig(opts.config)
+	if err != nil {
+		fmt.Fprintln(stderr, "could not load config:", err)
+		return exitError
+	}
+
+	app, err := gqlclientgen.New(config)
+	if err != nil {
+		fmt.Fprintln(stderr, "could not initialize app:", err)
+		return exitError
+	}
+
+	services, err := selectServices(app.Services, opts.services)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if name == "watch" {
+		if !watchServices(ctx, services, opts.interval, log, stderr) {
+			return exitError
+		}
+
+		return exitOK
+	}
+
+	// every service is processed so all the failures are reported
+	code := exitOK
+	for i := range services {
+		if err := runCommand(ctx, cmd, &services[i], log); err != nil {
+			printError(stderr, &services[i], err)
+			code = exitError
+		}
+	}
+
+	return code
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+
+	return command{}, false
+}
+
+// runCommand runs the command for the service, a panic is reported as an error
+func runCommand(ctx context.Context, cmd command, s *gqlclientgen.Service, log *logger) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("recovered from panic: %v", r)
+		}
+	}()
+
+	log.Debugf("%s service %s", cmd.name, s.Name)
+
+	return cmd.run(ctx, s, log)
+}
+
+// selectServices returns the services matching the names, which are either
+// service names or package names, or all the services if there are none
+func selectServices(services []gqlclientgen.Service, names []string) ([]gqlclientgen.Service, error) {
+	if len(names) == 0 {
+		return services, nil
+	}
+
+	var selected []gqlclientgen.Service
+	for _, name := range names {
+		found := false
+		for _, s := range services {
+			if s.Name == name || s.Package == name {
+				selected = append(selected, s)
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("unknown service %q", name)
+		}
+	}
+
+	return selected, nil
+}
+
+func printError(w io.Writer, s *gqlclientgen.Service, err error) {
+	// operation errors are printed as they are, one error per line
+	var operationErrs gqlclientgen.OperationErrors
+	if errors.As(err, &operationErrs) {
+		fmt.Fprintln(w, operationErrs)
+		return
+	}
+
+	fmt.Fprintf(w, "service %s: %v\n", s.Name, err)
+}
+
+// initConfig writes the sample config, an existing file is left untouched
+func initConfig(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("config file %s already exists", path)
+		}
+
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(sampleConfig)
+	return err
+}
+
+func generateService(ctx context.Context, s *gqlclientgen.Service, log *logger) error {
+	if err := resolveSchema(ctx, s, log); err != nil {
+		return err
+	}
+
+	if err := resolveOperations(s, log); err != nil {
+		return err
+	}
+
+	// the files are the ones compared by check
+	files, err := s.GenerateFiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		log.Debugf("writing %s", file.Path)
+		if err := file.Write(); err != nil {
+			return err
+		}
+	}
+
+	log.Infof("generated client for service %s in %s", s.Name, s.ClientFolder)
+
+	return nil
+}
+
+// errBreakingChanges is returned by schema pull when operations of the
+// service are affected by breaking changes of the new schema
+var errBreakingChanges = errors.New("operations are affected by breaking changes of the schema")
+
+// pullSchema fetches the schema, ignoring the cache, and writes the schema
+// files. The changes from the previous schema are reported along with the
+// operations they affect.
+func pullSchema(ctx context.Context, s *gqlclientgen.Service, log *logger) error {
+	s.Refresh = true
+
+	// the schema written by the previous run, if any
+	oldSchema, err := s.WrittenSchema()
+	if err != nil {
+		log.Infof("previous schema of service %s is ignored: %v", s.Name, err)
+	}
+
+	if err := resolveSchema(ctx, s, log); err != nil {
+		return err
+	}
+
+	if err := s.GenerateIntrospectionFile(); err != nil {
+		return err
+	}
+
+	if err := s.GenerateSchemaFile(); err != nil {
+		return err
+	}
+
+	log.Infof("wrote schema of service %s in %s", s.Name, s.ClientFolder)
+
+	if oldSchema == nil {
+		return nil
+	}
+
+	return reportSchemaChanges(s, oldSchema, log)
+}
+
+// reportSchemaChanges prints the changes from the old schema to the schema of
+// the service, and the operations affected by them
+func reportSchemaChanges(s *gqlclientgen.Service, oldSchema *ast.Schema, log *logger) error {
+	changes := schemadiff.Compare(oldSchema, s.SchemaDoc)
+	if len(changes) == 0 {
+		log.Infof("schema of service %s has not changed", s.Name)
+		return nil
+	}
+
+	log.Printf("schema changes of service %s:\n", s.Name)
+	for _, change := range changes {
+		log.Printf("  %-9s %s\n", change.Level, change.Message)
+	}
+
+	// the operations are only parsed, they are not valid with the new schema
+	// if they are affected by a breaking change
+	if _, err := s.ParseOperations(); err != nil {
+		return err
+	}
+
+	breaking := false
+	for _, impact := range schemadiff.Affected(oldSchema, s.OperationsDoc, changes) {
+		log.Printf("operation %s (%s) is affected by:\n", impact.Operation.Name, impact.Operation.Position.Src.Name)
+		for _, change := range impact.Changes {
+			log.Printf("  %-9s %s\n", change.Level, change.Message)
+			breaking = breaking || change.Level == schemadiff.Breaking
+		}
+	}
+
+	if breaking {
+		return errBreakingChanges
+	}
+
+	return nil
+}
+
+func validateService(ctx context.Context, s *gqlclientgen.Service, log *logger) error {
+	if err := resolveSchema(ctx, s, log); err != nil {
+		return err
+	}
+
+	if err := resolveOperations(s, log); err != nil {
+		return err
+	}
+
+	log.Infof("operations of service %s are valid", s.Name)
+
+	return nil
+}
+
+// errOutOfDate is returned by check when the files on disk differ from the
+// generated ones
+var errOutOfDate = errors.New("generated files are out of date, run gqlclientgen generate")
+
+func checkService(ctx context.Context, s *gqlclientgen.Service, log *logger) error {
+	if err := resolveSchema(ctx, s, log); err != nil {
+		return err
+	}
+
+	if err := resolveOperations(s, log); err != nil {
+		return err
+	}
+
+	files, err := s.GenerateFiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	upToDate := true
+	for _, file := range files {
+		log.Debugf("checking %s", file.Path)
+
+		current, err := os.ReadFile(file.Path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to read file %s: %w", file.Path, err)
+		}
+
+		if diff := unifiedDiff(file.Path, file.Path+" (generated)", string(current), string(file.Content)); diff != "" {
+			log.Printf("%s", diff)
+			upToDate = false
+		}
+	}
+
+	if !upToDate {
+		return errOutOfDate
+	}
+
+	log.Infof("generated files of service %s are up to date", s.Name)
+
+	return nil
+}
+
+func resolveSchema(ctx context.Context, s *gqlclientgen.Service, log *logger) error {
+	log.Debugf("loading schema from %s", s.SchemaSource())
+
+	return s.ResolveSchema(ctx)
+}
+
+func resolveOperations(s *gqlclientgen.Service, log *logger) error {
+	log.Debugf("reading operations from %s", s.OperationsFolder)
+
+	if err := s.ResolveOperations(); err != nil {
+		return err
+	}
+
+	log.Debugf("found %d operations and %d fragments", len(s.OperationsDoc.Operations), len(s.OperationsDoc.Fragments))
+
+	return nil
+}
+
+// logger prints the progress of the commands depending on the verbosity
+type logger struct {
+	out     io.Writer
+	verbose bool
+	quiet   bool
+}
+
+func newLogger(out io.Writer, opts options) *logger {
+	return &logger{
+		out:     out,
+		verbose: opts.verbose,
+		quiet:   opts.quiet,
+	}
+}
+
+// Infof prints unless the logger is quiet
+func (l *logger) Infof(format string, args ...interface{}) {
+	if !l.quiet {
+		fmt.Fprintf(l.out, format+"\n", args...)
+	}
+}
+
+// Printf always prints, it is used for the output of the commands
+func (l *logger) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(l.out, format, args...)
+}
+
+// Debugf prints only if the logger is verbose
+func (l *logger) Debugf(format string, args ...interface{}) {
+	if l.verbose {
+		fmt.Fprintf(l.out, format+"\n", args...)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stefanprifti/gqlclientgen"
+)
+
+func TestCLI(t *testing.T) {
+	testdata, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+
+	invalidOperations := filepath.Join(dir, "invalid")
+	if err := os.Mkdir(invalidOperations, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(invalidOperations, "hero.graphql"), []byte("query Hero {\n  hero { foo }\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := filepath.Join(dir, "gqlclientgen.yml")
+	err = os.WriteFile(config, []byte(fmt.Sprintf(`version: 1
+services:
+  - name: Star Wars API
+    package: starwars
+    schema: %[1]s/starwars.graphql
+    operations:
+      root: %[1]s/gql/starwars
+    client:
+      root: %[2]s/pkg/starwars
+  - name: Invalid API
+    package: invalid
+    schema: %[1]s/starwars.graphql
+    operations:
+      root: %[3]s
+    client:
+      root: %[2]s/pkg/invalid
+`, testdata, dir, invalidOperations)), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{
+			name:   "validate service",
+			args:   []string{"validate", "--config", config, "--service", "starwars"},
+			code:   exitOK,
+			stdout: "operations of service Star Wars API are valid\n",
+		},
+		{
+			name:   "validate errors",
+			args:   []string{"validate", "--config", config, "--quiet"},
+			code:   exitError,
+			stderr: filepath.Join(invalidOperations, "hero.graphql") + `:2:10: Cannot query field "foo" on type "Character".` + "\n",
+		},
+		{
+			name:   "unknown service",
+			args:   []string{"validate", "--config", config, "--service", "foo"},
+			code:   exitUsage,
+			stderr: "unknown service \"foo\"\n",
+		},
+		{
+			name:   "missing config",
+			args:   []string{"generate", "--config", filepath.Join(dir, "missing.yml")},
+			code:   exitError,
+			stderr: "could not load config: config file not found: " + filepath.Join(dir, "missing.yml") + "\n",
+		},
+		{
+			name:   "generate service",
+			args:   []string{"--config", config, "--service", "Star Wars API", "--quiet"},
+			code:   exitOK,
+			stdout: "",
+		},
+		{
+			name:   "flags before the command",
+			args:   []string{"--config", config, "generate", "--service", "starwars", "--quiet"},
+			code:   exitOK,
+			stdout: "",
+		},
+		{
+			name:   "service flag before the command",
+			args:   []string{"--config", config, "--service", "starwars", "validate"},
+			code:   exitOK,
+			stdout: "operations of service Star Wars API are valid\n",
+		},
+		{
+			name:   "arguments after the command",
+			args:   []string{"--config", config, "validate", "starwars"},
+			code:   exitUsage,
+			stderr: "unexpected arguments: starwars\n",
+		},
+		{
+			name:   "invalid interval",
+			args:   []string{"watch", "--config", config, "--interval", "0s"},
+			code:   exitUsage,
+			stderr: "--interval must be positive\n",
+		},
+		{
+			name:   "check up to date",
+			args:   []string{"check", "--config", config, "--service", "starwars"},
+			code:   exitOK,
+			stdout: "generated files of service Star Wars API are up to date\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runCLI(tt.args, &stdout, &stderr)
+			if code != tt.code {
+				t.Fatalf("expected exit code %d, got %d: %s", tt.code, code, stderr.String())
+			}
+
+			if stdout.String() != tt.stdout {
+				t.Errorf("expected stdout %q, got %q", tt.stdout, stdout.String())
+			}
+
+			if tt.stderr != "" && stderr.String() != tt.stderr {
+				t.Errorf("expected stderr %q, got %q", tt.stderr, stderr.String())
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "pkg", "starwars", gqlclientgen.ClientFileName)); err != nil {
+		t.Errorf("expected the client to be generated: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "pkg", "invalid")); !os.IsNotExist(err) {
+		t.Errorf("expected the invalid service to be skipped")
+	}
+
+	// check reports the changed file without writing it
+	model := filepath.Join(dir, "pkg", "starwars", gqlclientgen.ModelFileName)
+	if err := os.WriteFile(model, []byte("package starwars\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI([]string{"check", "--config", config, "--service", "starwars"}, &stdout, &stderr); code != exitError {
+		t.Fatalf("expected exit code %d, got %d", exitError, code)
+	}
+
+	if !strings.HasPrefix(stdout.String(), "--- "+model+"\n+++ "+model+" (generated)\n@@ -1,1 +1,") {
+		t.Errorf("unexpected diff %s", stdout.String())
+	}
+
+	if content, _ := os.ReadFile(model); string(content) != "package starwars\n" {
+		t.Errorf("expected check not to write %s", model)
+	}
+}
+
+func TestCLIInit(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "gqlclientgen.yml")
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI([]string{"init", "--config", config}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d: %s", exitOK, code, stderr.String())
+	}
+
+	if _, err := gqlclientgen.LoadConfig(config); err != nil {
+		t.Fatal(err)
+	}
+
+	// an existing config is not overwritten
+	if code := runCLI([]string{"init", "--config", config}, &stdout, &stderr); code != exitError {
+		t.Fatalf("expected exit code %d, got %d", exitError, code)
+	}
+
+	if !strings.Contains(stderr.String(), "already exists") {
+		t.Fatalf("unexpected error %q", stderr.String())
+	}
+
+	if code := runCLI([]string{"foo"}, &stdout, &stderr); code != exitUsage {
+		t.Fatalf("expected exit code %d, got %d", exitUsage, code)
+	}
+}
+
+func TestCLISchemaPull(t *testing.T) {
+	schemaJSON, err := os.ReadFile("testdata/pkg/countries/schema.introspect.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data":{"__schema":%s}}`, schemaJSON)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	client := filepath.Join(dir, "pkg")
+	operations := filepath.Join(dir, "gql")
+	for _, d := range []string{client, operations} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the previous schema has a field which is removed by the new one
+	schemaSDL, err := os.ReadFile("testdata/pkg/countries/schema.graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldSchema := strings.Replace(string(schemaSDL), "type Country {\n", "type Country {\n\tpopulation: Int\n", 1)
+	if err := os.WriteFile(filepath.Join(client, gqlclientgen.SchemaFileName), []byte(oldSchema), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	operation := filepath.Join(operations, "country.graphql")
+	if err := os.WriteFile(operation, []byte(`query Country { country(code: "DE") { name population } }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := filepath.Join(dir, "gqlclientgen.yml")
+	err = os.WriteFile(config, []byte(fmt.Sprintf(`version: 1
+services:
+  - name: Countries API
+    package: countries
+    url: %s
+    operations:
+      root: %s
+    client:
+      root: %s
+`, server.URL, operations, client)), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI([]string{"schema", "pull", "--config", config, "--quiet"}, &stdout, &stderr); code != exitError {
+		t.Fatalf("expected exit code %d, got %d: %s", exitError, code, stderr.String())
+	}
+
+	expected := "schema changes of service Countries API:\n" +
+		"  BREAKING  field Country.population was removed\n" +
+		"operation Country (" + operation + ") is affected by:\n" +
+		"  BREAKING  field Country.population was removed\n"
+	if stdout.String() != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, stdout.String())
+	}
+
+	if !strings.Contains(stderr.String(), errBreakingChanges.Error()) {
+		t.Fatalf("unexpected error %q", stderr.String())
+	}
+
+	// the new schema is written, so the next pull has no changes
+	stdout.Reset()
+	if code := runCLI([]string{"schema", "pull", "--config", config}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d: %s", exitOK, code, stderr.String())
+	}
+
+	if !strings.Contains(stdout.String(), "schema of service Countries API has not changed") {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
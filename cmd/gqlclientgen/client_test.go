@@ -0,0 +1,481 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stefanprifti/gqlclientgen/cmd/gqlclientgen/testdata/pkg/starwars"
+)
+
+// TestClientErrors runs the client generated in testdata/pkg/starwars
+func TestClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+			"data": {"hero": {"__typename": "Droid", "id": "2001", "name": "R2-D2", "friends": null, "primaryFunction": "Astromech"}},
+			"errors": [{
+				"message": "friends unavailable",
+				"locations": [{"line": 5, "column": 5}],
+				"path": ["hero", "friends"],
+				"extensions": {"code": "UNAVAILABLE"}
+			}]
+		}`)
+	}))
+	defer server.Close()
+
+	t.Run("errors", func(t *testing.T) {
+		client := starwars.NewClient(server.URL)
+
+		resp, err := client.Hero(context.Background(), &starwars.HeroRequest{})
+		if resp != nil {
+			t.Fatalf("expected no response, got %v", resp)
+		}
+
+		var errs starwars.GraphQLErrors
+		if !errors.As(err, &errs) {
+			t.Fatalf("expected GraphQLErrors, got %v", err)
+		}
+
+		if len(errs) != 1 || errs[0].PathString() != "hero.friends" || errs[0].Locations[0].Line != 5 {
+			t.Fatalf("unexpected errors %#v", errs)
+		}
+
+		if !errs.HasCode("UNAVAILABLE") {
+			t.Fatalf("expected code UNAVAILABLE, got %s", errs[0].Code())
+		}
+	})
+
+	t.Run("partial data", func(t *testing.T) {
+		client := starwars.NewClient(server.URL, starwars.WithPartialData())
+
+		resp, err := client.Hero(context.Background(), &starwars.HeroRequest{})
+		if err == nil {
+			t.Fatal("expected errors")
+		}
+
+		droid, ok := resp.Hero.Value.(starwars.HeroResponseHeroDroid)
+		if !ok || droid.PrimaryFunction != "Astromech" {
+			t.Fatalf("expected partial data, got %#v", resp)
+		}
+	})
+}
+
+func TestClientHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": {"hero": {"name": "R2-D2"}}}`)
+	}))
+	defer server.Close()
+
+	// a nil client falls back to the default one
+	client := starwars.NewClient(server.URL, starwars.WithHTTPClient(nil))
+
+	resp, err := client.HeroName(context.Background(), &starwars.HeroNameRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Hero.Name != "R2-D2" {
+		t.Fatalf("expected R2-D2, got %#v", resp)
+	}
+}
+
+func TestClientOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Static") != "static" || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.Query().Get("sleep") != "" {
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		fmt.Fprint(w, `{"data": {"hero": {"name": "R2-D2"}}}`)
+	}))
+	defer server.Close()
+
+	token := func(ctx context.Context) (http.Header, error) {
+		return http.Header{"Authorization": {"Bearer token"}}, nil
+	}
+
+	t.Run("headers and middlewares", func(t *testing.T) {
+		var calls []string
+		middleware := func(name string) starwars.Middleware {
+			return func(next http.RoundTripper) http.RoundTripper {
+				return starwars.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+					// the headers are set before the middlewares
+					calls = append(calls, name+" "+req.Header.Get("Authorization"))
+					return next.RoundTrip(req)
+				})
+			}
+		}
+
+		// the transport of the HTTP client passed as option is kept
+		transport := starwars.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls = append(calls, "transport")
+			return http.DefaultTransport.RoundTrip(req)
+		})
+
+		client := starwars.NewClient(server.URL,
+			starwars.WithHTTPClient(&http.Client{Transport: transport}),
+			starwars.WithHeader("X-Static", "static"),
+			starwars.WithHeaderProvider(token),
+			starwars.WithMiddleware(middleware("first")),
+			starwars.WithMiddleware(middleware("second")),
+		)
+
+		resp, err := client.HeroName(context.Background(), &starwars.HeroNameRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.Hero.Name != "R2-D2" {
+			t.Fatalf("expected R2-D2, got %#v", resp)
+		}
+
+		expected := []string{"first Bearer token", "second Bearer token", "transport"}
+		if !reflect.DeepEqual(calls, expected) {
+			t.Fatalf("expected calls %v, got %v", expected, calls)
+		}
+	})
+
+	t.Run("header provider error", func(t *testing.T) {
+		errToken := errors.New("no token")
+		client := starwars.NewClient(server.URL, starwars.WithHeaderProvider(func(ctx context.Context) (http.Header, error) {
+			return nil, errToken
+		}))
+
+		if _, err := client.HeroName(context.Background(), &starwars.HeroNameRequest{}); !errors.Is(err, errToken) {
+			t.Fatalf("expected the provider error, got %v", err)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		client := starwars.NewClient(server.URL+"?sleep=1",
+			starwars.WithHeader("X-Static", "static"),
+			starwars.WithHeaderProvider(token),
+			starwars.WithTimeout(10*time.Millisecond),
+		)
+
+		var netErr net.Error
+		if _, err := client.HeroName(context.Background(), &starwars.HeroNameRequest{}); !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Fatalf("expected a timeout, got %v", err)
+		}
+	})
+}
+
+// TestClientFragments decodes a response into the fragment structs of the
+// client generated in testdata/pkg/starwars
+func TestClientFragments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+			"data": {"hero": {"name": "R2-D2", "friends": [{"id": "1000", "name": "Luke Skywalker"}]}}
+		}`)
+	}))
+	defer server.Close()
+
+	client := starwars.NewClient(server.URL)
+
+	resp, err := client.HeroFriends(context.Background(), &starwars.HeroFriendsRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Hero.CharacterNameFragment.Name != "R2-D2" {
+		t.Errorf("expected the fragment name R2-D2, got %#v", resp.Hero.CharacterNameFragment)
+	}
+
+	// CharacterFriends also selects friends, so it is merged with the outer field
+	if len(resp.Hero.Friends) != 1 || resp.Hero.Friends[0].Id != "1000" || resp.Hero.Friends[0].Name != "Luke Skywalker" {
+		t.Errorf("expected the merged friends, got %#v", resp.Hero.Friends)
+	}
+
+	// an embedded fragment hidden by an outer field would be left empty
+	hero := reflect.ValueOf(resp.Hero)
+	for i := 0; i < hero.NumField(); i++ {
+		if hero.Type().Field(i).Anonymous && hero.Field(i).IsZero() {
+			t.Errorf("expected the fragment %s to be decoded", hero.Type().Field(i).Name)
+		}
+	}
+}
+
+// TestClientSubscription runs the subscriptions of the client generated in
+// testdata/pkg/starwars against an in-process graphql-transport-ws server.
+func TestClientSubscription(t *testing.T) {
+	t.Run("events", func(t *testing.T) {
+		server := httptest.NewServer(wsHandler(t, func(conn *testWSConn, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer token" {
+				t.Errorf("expected Authorization header, got %q", r.Header.Get("Authorization"))
+			}
+
+			init := conn.read()
+			if init.Type != "connection_init" || string(init.Payload) != `{"token":"secret"}` {
+				t.Errorf("unexpected init message %+v", init)
+			}
+			conn.write(testWSMessage{Type: "connection_ack"})
+
+			sub := conn.read()
+			var payload struct {
+				Query     string
+				Variables map[string]interface{}
+			}
+			if err := json.Unmarshal(sub.Payload, &payload); err != nil || sub.Type != "subscribe" {
+				t.Errorf("unexpected subscribe message %+v", sub)
+			}
+			if payload.Variables["episode"] != "JEDI" {
+				t.Errorf("unexpected variables %v", payload.Variables)
+			}
+
+			conn.write(testWSMessage{ID: sub.ID, Type: "ping"})
+			conn.write(testWSMessage{ID: sub.ID, Type: "next", Payload: json.RawMessage(`{"data": {"reviewAdded": {"stars": 5, "commentary": "great"}}}`)})
+			conn.write(testWSMessage{ID: sub.ID, Type: "next", Payload: json.RawMessage(`{"data": null, "errors": [{"message": "boom"}]}`)})
+			conn.write(testWSMessage{ID: sub.ID, Type: "complete"})
+		}))
+		defer server.Close()
+
+		client := starwars.NewClient(server.URL, starwars.WithHeader("Authorization", "Bearer token"), starwars.WithInitPayload(map[string]interface{}{"token": "secret"}))
+
+		events, err := client.ReviewAdded(context.Background(), &starwars.ReviewAddedRequest{Episode: starwars.JEDI})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var received []starwars.SubscriptionEvent[starwars.ReviewAddedResponse]
+		for event := range events {
+			received = append(received, event)
+		}
+
+		if len(received) != 2 {
+			t.Fatalf("expected 2 events, got %d", len(received))
+		}
+
+		if received[0].Err != nil || received[0].Response.ReviewAdded.Stars != 5 {
+			t.Fatalf("unexpected first event %+v", received[0])
+		}
+
+		var errs starwars.GraphQLErrors
+		if !errors.As(received[1].Err, &errs) || received[1].Response != nil {
+			t.Fatalf("expected GraphQLErrors, got %+v", received[1])
+		}
+	})
+
+	t.Run("message too big", func(t *testing.T) {
+		closeCode := make(chan uint16, 1)
+
+		server := httptest.NewServer(wsHandler(t, func(conn *testWSConn, r *http.Request) {
+			conn.read()
+			conn.write(testWSMessage{Type: "connection_ack"})
+			conn.read()
+
+			// announce a frame far larger than the maximum message size
+			frame := binary.BigEndian.AppendUint64([]byte{0x81, 127}, 1<<62)
+			if _, err := conn.conn.Write(frame); err != nil {
+				t.Error(err)
+				return
+			}
+
+			opcode, payload, err := conn.readFrame()
+			if err != nil || opcode != 0x8 || len(payload) < 2 {
+				t.Errorf("expected a close frame, got %d %v: %v", opcode, payload, err)
+				return
+			}
+			closeCode <- binary.BigEndian.Uint16(payload)
+		}))
+		defer server.Close()
+
+		client := starwars.NewClient(server.URL, starwars.WithMaxMessageSize(1024))
+
+		events, err := client.ReviewAdded(context.Background(), &starwars.ReviewAddedRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		event := <-events
+		if !errors.Is(event.Err, starwars.ErrMessageTooBig) {
+			t.Fatalf("expected ErrMessageTooBig, got %v", event.Err)
+		}
+
+		select {
+		case code := <-closeCode:
+			if code != 1009 {
+				t.Fatalf("expected the close code 1009, got %d", code)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected a close frame")
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		completed := make(chan struct{})
+
+		server := httptest.NewServer(wsHandler(t, func(conn *testWSConn, r *http.Request) {
+			conn.read()
+			conn.write(testWSMessage{Type: "connection_ack"})
+			sub := conn.read()
+
+			// keep sending until the client completes the subscription
+			go func() {
+				for {
+					if err := conn.write(testWSMessage{ID: sub.ID, Type: "next", Payload: json.RawMessage(`{"data": {"reviewAdded": {"stars": 3}}}`)}); err != nil {
+						return
+					}
+					select {
+					case <-completed:
+						return
+					case <-time.After(10 * time.Millisecond):
+					}
+				}
+			}()
+
+			if msg := conn.read(); msg.Type == "complete" && msg.ID == sub.ID {
+				close(completed)
+			}
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		events, err := starwars.NewClient(server.URL).ReviewAdded(ctx, &starwars.ReviewAddedRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if event := <-events; event.Err != nil {
+			t.Fatal(event.Err)
+		}
+
+		cancel()
+
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case _, ok := <-events:
+				if !ok {
+					select {
+					case <-completed:
+						return
+					case <-timeout:
+						t.Fatal("expected the subscription to be completed")
+					}
+				}
+			case <-timeout:
+				t.Fatal("expected the channel to be closed")
+			}
+		}
+	})
+}
+
+type testWSMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// testWSConn is the server side of a WebSocket connection, it only handles
+// unfragmented text frames.
+type testWSConn struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// wsHandler upgrades the request to a WebSocket connection handled by handle.
+func wsHandler(t *testing.T, handle func(conn *testWSConn, r *http.Request)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" || r.Header.Get("Sec-WebSocket-Protocol") != "graphql-transport-ws" {
+			t.Errorf("unexpected upgrade headers %v", r.Header)
+		}
+
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		accept := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+		fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\nSec-WebSocket-Protocol: graphql-transport-ws\r\n\r\n", base64.StdEncoding.EncodeToString(accept[:]))
+		rw.Flush()
+
+		handle(&testWSConn{t: t, conn: conn, reader: rw.Reader}, r)
+	})
+}
+
+func (c *testWSConn) read() testWSMessage {
+	for {
+		opcode, payload, err := c.readFrame()
+		if err != nil {
+			return testWSMessage{}
+		}
+
+		// skip the control frames
+		if opcode != 0x1 {
+			continue
+		}
+
+		var msg testWSMessage
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			c.t.Error(err)
+		}
+
+		return msg
+	}
+}
+
+// readFrame reads a frame and returns its opcode and unmasked payload.
+func (c *testWSConn) readFrame() (byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		return 0, nil, err
+	}
+
+	if header[1]&0x80 == 0 {
+		c.t.Error("expected a masked frame")
+	}
+
+	length := int(header[1] & 0x7f)
+	if length == 126 {
+		ext := make([]byte, 2)
+		io.ReadFull(c.reader, ext)
+		length = int(binary.BigEndian.Uint16(ext))
+	}
+
+	mask := make([]byte, 4)
+	io.ReadFull(c.reader, mask)
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return header[0] & 0x0f, payload, nil
+}
+
+func (c *testWSConn) write(msg testWSMessage) error {
+	data, _ := json.Marshal(msg)
+
+	frame := []byte{0x81, byte(len(data))}
+	if len(data) >= 126 {
+		frame = []byte{0x81, 126}
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(data)))
+	}
+
+	_, err := c.conn.Write(append(frame, data...))
+	return err
+}
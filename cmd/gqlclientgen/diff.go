@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around the changes of a hunk
+const diffContext = 3
+
+// diffOp is a line of a diff: ' ' when it is in both texts, '-' when it is
+// only in the old one and '+' when it is only in the new one
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// unifiedDiff returns the diff of the texts in the unified format, or an
+// empty string if they are equal
+func unifiedDiff(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+
+	ops := diffLines(splitLines(oldText), splitLines(newText))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+
+	for start := 0; start < len(ops); {
+		// find the next change
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// extend the hunk while the changes are close enough
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				end = i + 1
+			} else if i-end >= 2*diffContext {
+				break
+			}
+		}
+
+		first := start - diffContext
+		if first < 0 {
+			first = 0
+		}
+
+		last := end + diffContext
+		if last > len(ops) {
+			last = len(ops)
+		}
+
+		writeHunk(&b, ops, first, last)
+
+		start = last
+	}
+
+	return b.String()
+}
+
+func writeHunk(b *strings.Builder, ops []diffOp, first, last int) {
+	var oldStart, newStart int
+	for _, op := range ops[:first] {
+		if op.kind != '+' {
+			oldStart++
+		}
+		if op.kind != '-' {
+			newStart++
+		}
+	}
+
+	var oldCount, newCount int
+	for _, op := range ops[first:last] {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+
+	// the start of an empty range is the line before it
+	if oldCount > 0 {
+		oldStart++
+	}
+	if newCount > 0 {
+		newStart++
+	}
+
+	fmt.Fprintf(b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+
+	for _, op := range ops[first:last] {
+		b.WriteByte(op.kind)
+		b.WriteString(op.line)
+		if !strings.HasSuffix(op.line, "\n") {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// splitLines splits the text after each newline, so the lines keep it
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// diffMaxCost bounds the work spent looking for the shortest diff of a range
+// of lines, past it the lines are reported as removed and added
+const diffMaxCost = 1 << 26
+
+// diffLines computes the lines to remove from a and to add to get b, using
+// the linear space variant of the Myers algorithm
+func diffLines(a, b []string) []diffOp {
+	ops := appendDiff(nil, a, b)
+
+	// the removed lines of a change come before the added ones
+	for start := 0; start < len(ops); {
+		if ops[start].kind == ' ' {
+			start++
+			continue
+		}
+
+		end := start
+		for end < len(ops) && ops[end].kind != ' ' {
+			end++
+		}
+		sort.SliceStable(ops[start:end], func(i, j int) bool {
+			return ops[start+i].kind == '-' && ops[start+j].kind == '+'
+		})
+		start = end
+	}
+
+	return ops
+}
+
+// appendDiff appends the diff of a and b to ops
+func appendDiff(ops []diffOp, a, b []string) []diffOp {
+	// the common prefix and suffix are left out of the comparison
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		ops = append(ops, diffOp{' ', a[prefix]})
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	x, y, u, v, ok := middleSnake(ma, mb)
+	if ok {
+		// the snake is part of a shortest diff, the lines before and after it
+		// are compared separately
+		ops = appendDiff(ops, ma[:x], mb[:y])
+		for _, line := range ma[x:u] {
+			ops = append(ops, diffOp{' ', line})
+		}
+		ops = appendDiff(ops, ma[u:], mb[v:])
+	} else {
+		for _, line := range ma {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range mb {
+			ops = append(ops, diffOp{'+', line})
+		}
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	return ops
+}
+
+// middleSnake returns the snake, a run of equal lines from (x, y) to (u, v),
+// in the middle of a shortest diff of a and b. It searches from both ends at
+// once and only keeps the furthest point reached on each diagonal, so it uses
+// linear space. ok is false when a or b is empty or when the search is given
+// up because it would cost more than diffMaxCost.
+func middleSnake(a, b []string) (x, y, u, v int, ok bool) {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return 0, 0, 0, 0, false
+	}
+
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	if limit := diffMaxCost / (n + m); maxD > limit {
+		maxD = limit
+	}
+
+	// forward[offset+k] is the furthest x reached from the start on the
+	// diagonal k = x - y, backward[offset+k] is the furthest distance from
+	// the end reached on the diagonal k of the reversed texts
+	offset := maxD + 1
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && forward[offset+k-1] < forward[offset+k+1] {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+
+			startX, startY := x, y
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+
+			// the backward diagonal delta - k is the same diagonal
+			if c := delta - k; odd && c >= -(d-1) && c <= d-1 && x+backward[offset+c] >= n {
+				return startX, startY, x, y, true
+			}
+		}
+
+		for c := -d; c <= d; c += 2 {
+			var x int
+			if c == -d || c != d && backward[offset+c-1] < backward[offset+c+1] {
+				x = backward[offset+c+1]
+			} else {
+				x = backward[offset+c-1] + 1
+			}
+			y := x - c
+
+			startX, startY := x, y
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+c] = x
+
+			if k := delta - c; !odd && k >= -d && k <= d && x+forward[offset+k] >= n {
+				return n - x, m - y, n - startX, m - startY, true
+			}
+		}
+	}
+
+	return 0, 0, 0, 0, false
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		new      string
+		expected string
+	}{
+		{
+			name: "equal",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+		},
+		{
+			name: "changed line",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			new:  "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			expected: `--- old
++++ new
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+`,
+		},
+		{
+			name: "distant changes",
+			old:  "a\n1\n2\n3\n4\n5\n6\n7\nb\n",
+			new:  "1\n2\n3\n4\n5\n6\n7\nc\n",
+			expected: `--- old
++++ new
+@@ -1,4 +1,3 @@
+-a
+ 1
+ 2
+ 3
+@@ -6,4 +5,4 @@
+ 5
+ 6
+ 7
+-b
++c
+`,
+		},
+		{
+			name: "new file",
+			old:  "",
+			new:  "a\nb",
+			expected: `--- old
++++ new
+@@ -0,0 +1,2 @@
++a
++b
+\ No newline at end of file
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := unifiedDiff("old", "new", tt.old, tt.new)
+			if diff != tt.expected {
+				t.Fatalf("expected\n%s\ngot\n%s", tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	// check reports whether the ops turn a into b
+	check := func(t *testing.T, a, b []string, ops []diffOp) {
+		t.Helper()
+
+		var oldLines, newLines []string
+		for _, op := range ops {
+			if op.kind != '+' {
+				oldLines = append(oldLines, op.line)
+			}
+			if op.kind != '-' {
+				newLines = append(newLines, op.line)
+			}
+		}
+
+		if fmt.Sprint(oldLines) != fmt.Sprint(a) || fmt.Sprint(newLines) != fmt.Sprint(b) {
+			t.Fatalf("the diff of %v and %v does not apply: %v", a, b, ops)
+		}
+	}
+
+	t.Run("shortest", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		random := func() []string {
+			lines := make([]string, r.Intn(12))
+			for i := range lines {
+				lines[i] = string(rune('a' + r.Intn(3)))
+			}
+			return lines
+		}
+
+		for i := 0; i < 1000; i++ {
+			a, b := random(), random()
+			ops := diffLines(a, b)
+			check(t, a, b, ops)
+
+			common := 0
+			for _, op := range ops {
+				if op.kind == ' ' {
+					common++
+				}
+			}
+			if expected := lcsLength(a, b); common != expected {
+				t.Fatalf("expected %d common lines between %v and %v, got %d", expected, a, b, common)
+			}
+		}
+	})
+
+	t.Run("large", func(t *testing.T) {
+		a := make([]string, 50000)
+		b := make([]string, 50000)
+		for i := range a {
+			a[i] = fmt.Sprintf("line %d\n", i)
+			b[i] = fmt.Sprintf("other %d\n", i)
+		}
+		copy(b[20000:30000], a[20000:30000])
+
+		start := time.Now()
+		ops := diffLines(a, b)
+		check(t, a, b, ops)
+
+		if elapsed := time.Since(start); elapsed > 10*time.Second {
+			t.Fatalf("expected the diff to be bounded, took %s", elapsed)
+		}
+	})
+}
+
+// lcsLength returns the length of the longest common subsequence of a and b
+func lcsLength(a, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	return lcs[0][0]
+}
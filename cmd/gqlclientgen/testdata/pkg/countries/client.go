@@ -1,29 +1,286 @@
 package countries
 
 import (
+	"bytes"
 	"context"
-
-	"github.com/stefanprifti/gqlclient"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
 )
 
 type Client struct {
-	URL       string
-	gqlclient *gqlclient.Client
+	URL         string
+	httpClient  *http.Client
+	partialData bool
+}
+
+// Option configures the Client.
+type Option func(*clientOptions)
+
+// HeaderProvider returns the headers added to a request, it is called for
+// every request so the headers can depend on the context.
+type HeaderProvider func(ctx context.Context) (http.Header, error)
+
+// Middleware wraps the transport used to send the requests, so the requests
+// and the responses can be inspected or changed.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an http.RoundTripper implemented by a function, it
+// helps writing a Middleware.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+type clientOptions struct {
+	httpClient      *http.Client
+	timeout         time.Duration
+	headers         http.Header
+	headerProviders []HeaderProvider
+	middlewares     []Middleware
+	partialData     bool
+}
+
+// WithHTTPClient sets the HTTP client used to send the requests, nil means
+// http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *clientOptions) {
+		o.httpClient = httpClient
+	}
+}
+
+// WithTimeout sets the timeout of the requests.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *clientOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithHeader adds a header to every request.
+func WithHeader(key, value string) Option {
+	return func(o *clientOptions) {
+		o.headers.Add(key, value)
+	}
+}
+
+// WithHeaderProvider adds the headers returned by the provider to every request.
+func WithHeaderProvider(provider HeaderProvider) Option {
+	return func(o *clientOptions) {
+		o.headerProviders = append(o.headerProviders, provider)
+	}
+}
+
+// WithMiddleware adds a middleware, the first middleware added is the first to see the request.
+func WithMiddleware(middleware Middleware) Option {
+	return func(o *clientOptions) {
+		o.middlewares = append(o.middlewares, middleware)
+	}
 }
 
-func NewClient(url string) *Client {
+// WithPartialData makes the methods return the data of a response together
+// with its GraphQLErrors, instead of only the errors.
+func WithPartialData() Option {
+	return func(o *clientOptions) {
+		o.partialData = true
+	}
+}
+
+func NewClient(url string, opts ...Option) *Client {
+	o := clientOptions{
+		httpClient: http.DefaultClient,
+		headers:    http.Header{},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// a nil client is the default one
+	if o.httpClient == nil {
+		o.httpClient = http.DefaultClient
+	}
+
+	// copy the client so the one passed as option is not changed
+	httpClient := *o.httpClient
+	if o.timeout != 0 {
+		httpClient.Timeout = o.timeout
+	}
+
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	for i := len(o.middlewares) - 1; i >= 0; i-- {
+		transport = o.middlewares[i](transport)
+	}
+
+	// the headers are set before the middlewares see the request
+	if len(o.headers) > 0 || len(o.headerProviders) > 0 {
+		transport = headerTransport(transport, o.headers, o.headerProviders)
+	}
+
+	httpClient.Transport = transport
+
 	return &Client{
-		URL: url,
-		gqlclient: gqlclient.New(gqlclient.Options{
-			Endpoint: url,
-		}),
+		URL:         url,
+		httpClient:  &httpClient,
+		partialData: o.partialData,
 	}
 }
 
+// headerTransport adds the static headers and the headers of the providers to the requests.
+func headerTransport(next http.RoundTripper, headers http.Header, providers []HeaderProvider) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		for key, values := range headers {
+			req.Header[key] = values
+		}
+
+		for _, provider := range providers {
+			h, err := provider(req.Context())
+			if err != nil {
+				return nil, err
+			}
+
+			for key, values := range h {
+				req.Header[key] = values
+			}
+		}
+
+		return next.RoundTrip(req)
+	})
+}
+
+// GraphQLError is an error of a GraphQL response.
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Locations  []GraphQLErrorLocation `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLErrorLocation is the location in the query of a GraphQLError.
+type GraphQLErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (e *GraphQLError) Error() string {
+	if len(e.Path) == 0 {
+		return "graphql: " + e.Message
+	}
+
+	return fmt.Sprintf("graphql: %s at %s", e.Message, e.PathString())
+}
+
+// PathString returns the path of the field which caused the error, e.g. country.languages.0.name
+func (e *GraphQLError) PathString() string {
+	parts := make([]string, 0, len(e.Path))
+	for _, p := range e.Path {
+		parts = append(parts, fmt.Sprint(p))
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// Code returns the code of the error extensions, if any.
+func (e *GraphQLError) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+// GraphQLErrors are the errors of a GraphQL response.
+type GraphQLErrors []*GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// HasCode reports whether one of the errors has the extension code.
+func (e GraphQLErrors) HasCode(code string) bool {
+	for _, err := range e {
+		if err.Code() == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+type graphQLRequest struct {
+	Query     string      `json:"query"`
+	Variables interface{} `json:"variables,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors GraphQLErrors   `json:"errors"`
+}
+
+// do sends the operation and decodes the data of the response into resp. It
+// reports whether the response had data, which may come along with errors.
+func (c *Client) do(ctx context.Context, query string, variables interface{}, resp interface{}) (bool, error) {
+	body, err := json.Marshal(graphQLRequest{
+		Query:     query,
+		Variables: variables,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return false, fmt.Errorf("failed to do request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	var graphQLResp graphQLResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&graphQLResp); err != nil {
+		if httpResp.StatusCode != http.StatusOK {
+			return false, fmt.Errorf("unexpected status code: %d", httpResp.StatusCode)
+		}
+
+		return false, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	hasData := len(graphQLResp.Data) > 0 && string(graphQLResp.Data) != "null"
+	if hasData {
+		if err := json.Unmarshal(graphQLResp.Data, resp); err != nil {
+			return false, fmt.Errorf("failed to decode data: %w", err)
+		}
+	}
+
+	if len(graphQLResp.Errors) > 0 {
+		return hasData, graphQLResp.Errors
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status code: %d", httpResp.StatusCode)
+	}
+
+	return hasData, nil
+}
+
 func (c *Client) Country(ctx context.Context, req *CountryRequest) (*CountryResponse, error) {
 	var resp CountryResponse
 
-	query := `query Country($code: ID!) {
+	query := `query Country ($code: ID!) {
   country(code: $code) {
     name
     native
@@ -41,8 +298,12 @@ func (c *Client) Country(ctx context.Context, req *CountryRequest) (*CountryResp
 }
 `
 
-	err := c.gqlclient.Query(ctx, query, req, &resp)
+	hasData, err := c.do(ctx, query, req, &resp)
 	if err != nil {
+		if hasData && c.partialData {
+			return &resp, err
+		}
+
 		return nil, err
 	}
 
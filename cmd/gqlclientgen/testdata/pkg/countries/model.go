@@ -54,18 +54,16 @@ type CountryRequest struct {
 	Code string `json:"code"`
 }
 type CountryResponse struct {
-	Country struct {
-		Name      string `json:"name"`
-		Native    string `json:"native"`
-		Languages struct {
-			Code string `json:"code"`
-			Name string `json:"name"`
-		} `json:"languages"`
-		Emoji     string `json:"emoji"`
-		Currency  string `json:"currency,omitempty"`
-		Languages struct {
-			Code string `json:"code"`
-			Name string `json:"name"`
-		} `json:"languages"`
-	} `json:"country,omitempty"`
+	Country CountryResponseCountry `json:"country,omitempty"`
+}
+type CountryResponseCountry struct {
+	Name      string                            `json:"name"`
+	Native    string                            `json:"native"`
+	Languages []CountryResponseCountryLanguages `json:"languages"`
+	Emoji     string                            `json:"emoji"`
+	Currency  string                            `json:"currency,omitempty"`
+}
+type CountryResponseCountryLanguages struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
 }
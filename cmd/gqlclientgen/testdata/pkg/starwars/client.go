@@ -0,0 +1,902 @@
+package starwars
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Client struct {
+	URL            string
+	httpClient     *http.Client
+	partialData    bool
+	initPayload    map[string]interface{}
+	maxMessageSize int64
+}
+
+// Option configures the Client.
+type Option func(*clientOptions)
+
+// HeaderProvider returns the headers added to a request, it is called for
+// every request so the headers can depend on the context.
+type HeaderProvider func(ctx context.Context) (http.Header, error)
+
+// Middleware wraps the transport used to send the requests, so the requests
+// and the responses can be inspected or changed.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an http.RoundTripper implemented by a function, it
+// helps writing a Middleware.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+type clientOptions struct {
+	httpClient      *http.Client
+	timeout         time.Duration
+	headers         http.Header
+	headerProviders []HeaderProvider
+	middlewares     []Middleware
+	partialData     bool
+	initPayload     map[string]interface{}
+	maxMessageSize  int64
+}
+
+// WithHTTPClient sets the HTTP client used to send the requests, nil means
+// http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *clientOptions) {
+		o.httpClient = httpClient
+	}
+}
+
+// WithTimeout sets the timeout of the requests.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *clientOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithHeader adds a header to every request.
+func WithHeader(key, value string) Option {
+	return func(o *clientOptions) {
+		o.headers.Add(key, value)
+	}
+}
+
+// WithHeaderProvider adds the headers returned by the provider to every request.
+func WithHeaderProvider(provider HeaderProvider) Option {
+	return func(o *clientOptions) {
+		o.headerProviders = append(o.headerProviders, provider)
+	}
+}
+
+// WithMiddleware adds a middleware, the first middleware added is the first to see the request.
+func WithMiddleware(middleware Middleware) Option {
+	return func(o *clientOptions) {
+		o.middlewares = append(o.middlewares, middleware)
+	}
+}
+
+// WithPartialData makes the methods return the data of a response together
+// with its GraphQLErrors, instead of only the errors.
+func WithPartialData() Option {
+	return func(o *clientOptions) {
+		o.partialData = true
+	}
+}
+
+// WithInitPayload sets the payload of the connection_init message sent when a
+// subscription starts, servers often expect the credentials there.
+func WithInitPayload(payload map[string]interface{}) Option {
+	return func(o *clientOptions) {
+		o.initPayload = payload
+	}
+}
+
+// DefaultMaxMessageSize is the maximum size of the subscription messages
+// received, unless it is set with WithMaxMessageSize.
+const DefaultMaxMessageSize = 16 << 20
+
+// ErrMessageTooBig is returned when a subscription message is larger than
+// the maximum message size, the connection is closed.
+var ErrMessageTooBig = errors.New("message too big")
+
+// WithMaxMessageSize sets the maximum size in bytes of the subscription
+// messages received from the server.
+func WithMaxMessageSize(size int64) Option {
+	return func(o *clientOptions) {
+		o.maxMessageSize = size
+	}
+}
+
+func NewClient(url string, opts ...Option) *Client {
+	o := clientOptions{
+		httpClient:     http.DefaultClient,
+		headers:        http.Header{},
+		maxMessageSize: DefaultMaxMessageSize,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// a nil client is the default one
+	if o.httpClient == nil {
+		o.httpClient = http.DefaultClient
+	}
+
+	// copy the client so the one passed as option is not changed
+	httpClient := *o.httpClient
+	if o.timeout != 0 {
+		httpClient.Timeout = o.timeout
+	}
+
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	for i := len(o.middlewares) - 1; i >= 0; i-- {
+		transport = o.middlewares[i](transport)
+	}
+
+	// the headers are set before the middlewares see the request
+	if len(o.headers) > 0 || len(o.headerProviders) > 0 {
+		transport = headerTransport(transport, o.headers, o.headerProviders)
+	}
+
+	httpClient.Transport = transport
+
+	return &Client{
+		URL:            url,
+		httpClient:     &httpClient,
+		partialData:    o.partialData,
+		initPayload:    o.initPayload,
+		maxMessageSize: o.maxMessageSize,
+	}
+}
+
+// headerTransport adds the static headers and the headers of the providers to the requests.
+func headerTransport(next http.RoundTripper, headers http.Header, providers []HeaderProvider) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		for key, values := range headers {
+			req.Header[key] = values
+		}
+
+		for _, provider := range providers {
+			h, err := provider(req.Context())
+			if err != nil {
+				return nil, err
+			}
+
+			for key, values := range h {
+				req.Header[key] = values
+			}
+		}
+
+		return next.RoundTrip(req)
+	})
+}
+
+// GraphQLError is an error of a GraphQL response.
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Locations  []GraphQLErrorLocation `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLErrorLocation is the location in the query of a GraphQLError.
+type GraphQLErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (e *GraphQLError) Error() string {
+	if len(e.Path) == 0 {
+		return "graphql: " + e.Message
+	}
+
+	return fmt.Sprintf("graphql: %s at %s", e.Message, e.PathString())
+}
+
+// PathString returns the path of the field which caused the error, e.g. country.languages.0.name
+func (e *GraphQLError) PathString() string {
+	parts := make([]string, 0, len(e.Path))
+	for _, p := range e.Path {
+		parts = append(parts, fmt.Sprint(p))
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// Code returns the code of the error extensions, if any.
+func (e *GraphQLError) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+// GraphQLErrors are the errors of a GraphQL response.
+type GraphQLErrors []*GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// HasCode reports whether one of the errors has the extension code.
+func (e GraphQLErrors) HasCode(code string) bool {
+	for _, err := range e {
+		if err.Code() == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+type graphQLRequest struct {
+	Query     string      `json:"query"`
+	Variables interface{} `json:"variables,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors GraphQLErrors   `json:"errors"`
+}
+
+// do sends the operation and decodes the data of the response into resp. It
+// reports whether the response had data, which may come along with errors.
+func (c *Client) do(ctx context.Context, query string, variables interface{}, resp interface{}) (bool, error) {
+	body, err := json.Marshal(graphQLRequest{
+		Query:     query,
+		Variables: variables,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return false, fmt.Errorf("failed to do request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	var graphQLResp graphQLResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&graphQLResp); err != nil {
+		if httpResp.StatusCode != http.StatusOK {
+			return false, fmt.Errorf("unexpected status code: %d", httpResp.StatusCode)
+		}
+
+		return false, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	hasData := len(graphQLResp.Data) > 0 && string(graphQLResp.Data) != "null"
+	if hasData {
+		if err := json.Unmarshal(graphQLResp.Data, resp); err != nil {
+			return false, fmt.Errorf("failed to decode data: %w", err)
+		}
+	}
+
+	if len(graphQLResp.Errors) > 0 {
+		return hasData, graphQLResp.Errors
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status code: %d", httpResp.StatusCode)
+	}
+
+	return hasData, nil
+}
+
+// SubscriptionEvent is a response of a subscription. Err is set when the
+// response has GraphQLErrors, Response may then hold partial data, or when
+// the subscription failed, in which case it is the last event.
+type SubscriptionEvent[T any] struct {
+	Response *T
+	Err      error
+}
+
+// message types of the graphql-transport-ws protocol
+const (
+	gqlConnectionInit = "connection_init"
+	gqlConnectionAck  = "connection_ack"
+	gqlPing           = "ping"
+	gqlPong           = "pong"
+	gqlSubscribe      = "subscribe"
+	gqlNext           = "next"
+	gqlError          = "error"
+	gqlComplete       = "complete"
+)
+
+type graphQLWSMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// subscribe runs the operation over the graphql-transport-ws protocol, using
+// a WebSocket connection dedicated to the subscription.
+func subscribe[T any](ctx context.Context, c *Client, query string, variables interface{}) (<-chan SubscriptionEvent[T], error) {
+	payload, err := json.Marshal(graphQLRequest{
+		Query:     query,
+		Variables: variables,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	conn, err := c.dialWebSocket(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// complete the subscription and unblock the reads when the context is done
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.writeMessage(graphQLWSMessage{ID: "1", Type: gqlComplete})
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	err = conn.init(c.initPayload)
+	if err == nil {
+		err = conn.writeMessage(graphQLWSMessage{ID: "1", Type: gqlSubscribe, Payload: payload})
+	}
+	if err != nil {
+		close(done)
+		conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		return nil, err
+	}
+
+	events := make(chan SubscriptionEvent[T])
+
+	go func() {
+		defer close(events)
+		defer conn.Close()
+		defer close(done)
+
+		send := func(event SubscriptionEvent[T]) bool {
+			select {
+			case events <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			msg, err := conn.readMessage()
+			if err != nil {
+				if ctx.Err() == nil {
+					send(SubscriptionEvent[T]{Err: err})
+				}
+				return
+			}
+
+			switch msg.Type {
+			case gqlPing:
+				if err := conn.writeMessage(graphQLWSMessage{Type: gqlPong}); err != nil {
+					send(SubscriptionEvent[T]{Err: fmt.Errorf("failed to write message: %w", err)})
+					return
+				}
+			case gqlNext:
+				var graphQLResp graphQLResponse
+				if err := json.Unmarshal(msg.Payload, &graphQLResp); err != nil {
+					send(SubscriptionEvent[T]{Err: fmt.Errorf("failed to decode response: %w", err)})
+					return
+				}
+
+				var event SubscriptionEvent[T]
+				if len(graphQLResp.Data) > 0 && string(graphQLResp.Data) != "null" {
+					var resp T
+					if err := json.Unmarshal(graphQLResp.Data, &resp); err != nil {
+						send(SubscriptionEvent[T]{Err: fmt.Errorf("failed to decode data: %w", err)})
+						return
+					}
+					event.Response = &resp
+				}
+
+				if len(graphQLResp.Errors) > 0 {
+					event.Err = graphQLResp.Errors
+				}
+
+				if !send(event) {
+					return
+				}
+			case gqlError:
+				var errs GraphQLErrors
+				if err := json.Unmarshal(msg.Payload, &errs); err != nil {
+					send(SubscriptionEvent[T]{Err: fmt.Errorf("failed to decode errors: %w", err)})
+					return
+				}
+
+				send(SubscriptionEvent[T]{Err: errs})
+				return
+			case gqlComplete:
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// webSocketGUID is used to compute the Sec-WebSocket-Accept header, see RFC 6455.
+const webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket frame opcodes
+const (
+	wsOpText  = 0x1
+	wsOpClose = 0x8
+	wsOpPing  = 0x9
+	wsOpPong  = 0xa
+)
+
+// wsConn is a minimal WebSocket client connection, enough to speak the
+// graphql-transport-ws protocol.
+type wsConn struct {
+	rwc            io.ReadWriteCloser
+	reader         *bufio.Reader
+	maxMessageSize int64
+	mu             sync.Mutex
+	closeOnce      sync.Once
+}
+
+// dialWebSocket opens a WebSocket connection to the URL of the client, ws://
+// and wss:// URLs are accepted as well as http:// and https:// ones.
+func (c *Client) dialWebSocket(ctx context.Context) (*wsConn, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	}
+
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	encodedKey := base64.StdEncoding.EncodeToString(key)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Connection", "Upgrade")
+	httpReq.Header.Set("Upgrade", "websocket")
+	httpReq.Header.Set("Sec-WebSocket-Version", "13")
+	httpReq.Header.Set("Sec-WebSocket-Key", encodedKey)
+	httpReq.Header.Set("Sec-WebSocket-Protocol", "graphql-transport-ws")
+
+	// the transport is used directly, the timeout of the http client would
+	// close the connection while the subscription is running
+	httpResp, err := c.httpClient.Transport.RoundTrip(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to do request: %w", err)
+	}
+
+	if httpResp.StatusCode != http.StatusSwitchingProtocols {
+		httpResp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code: %d", httpResp.StatusCode)
+	}
+
+	accept := sha1.Sum([]byte(encodedKey + webSocketGUID))
+	if httpResp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(accept[:]) {
+		httpResp.Body.Close()
+		return nil, errors.New("invalid Sec-WebSocket-Accept header")
+	}
+
+	rwc, ok := httpResp.Body.(io.ReadWriteCloser)
+	if !ok {
+		httpResp.Body.Close()
+		return nil, errors.New("response body is not writable")
+	}
+
+	return &wsConn{
+		rwc:            rwc,
+		reader:         bufio.NewReader(rwc),
+		maxMessageSize: c.maxMessageSize,
+	}, nil
+}
+
+// init sends the connection_init message and waits for the connection_ack.
+func (c *wsConn) init(payload map[string]interface{}) error {
+	msg := graphQLWSMessage{Type: gqlConnectionInit}
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal init payload: %w", err)
+		}
+		msg.Payload = data
+	}
+
+	if err := c.writeMessage(msg); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+
+	for {
+		msg, err := c.readMessage()
+		if err != nil {
+			return err
+		}
+
+		switch msg.Type {
+		case gqlConnectionAck:
+			return nil
+		case gqlPing:
+			if err := c.writeMessage(graphQLWSMessage{Type: gqlPong}); err != nil {
+				return fmt.Errorf("failed to write message: %w", err)
+			}
+		default:
+			return fmt.Errorf("unexpected message %q before %s", msg.Type, gqlConnectionAck)
+		}
+	}
+}
+
+func (c *wsConn) writeMessage(msg graphQLWSMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return c.writeFrame(wsOpText, data)
+}
+
+// readMessage reads the next message, answering pings and joining
+// fragmented frames on the way.
+func (c *wsConn) readMessage() (*graphQLWSMessage, error) {
+	var data []byte
+	for {
+		fin, opcode, payload, err := c.readFrame(c.maxMessageSize - int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read message: %w", err)
+		}
+
+		switch opcode {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return nil, fmt.Errorf("failed to write message: %w", err)
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			if len(payload) >= 2 {
+				return nil, fmt.Errorf("connection closed: %d %s", binary.BigEndian.Uint16(payload), payload[2:])
+			}
+			return nil, errors.New("connection closed")
+		}
+
+		data = append(data, payload...)
+		if fin {
+			break
+		}
+	}
+
+	var msg graphQLWSMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("failed to decode message: %w", err)
+	}
+
+	return &msg, nil
+}
+
+// writeFrame writes a single frame, masked as required for clients.
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode, 0x80}
+	switch n := len(payload); {
+	case n < 126:
+		frame[1] |= byte(n)
+	case n <= 0xffff:
+		frame[1] |= 126
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame[1] |= 127
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+
+	mask := make([]byte, 4)
+	if _, err := rand.Read(mask); err != nil {
+		return err
+	}
+	frame = append(frame, mask...)
+
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, err := c.rwc.Write(frame)
+	return err
+}
+
+// readFrame reads a single frame and unmasks its payload. The connection is
+// closed when the payload is larger than limit.
+func (c *wsConn) readFrame(limit int64) (bool, byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(c.reader, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err := io.ReadFull(c.reader, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext)
+	}
+
+	if limit < 0 || length > uint64(limit) {
+		c.close(1009)
+		return false, 0, nil, fmt.Errorf("%w: the maximum message size is %d bytes", ErrMessageTooBig, c.maxMessageSize)
+	}
+
+	mask := make([]byte, 4)
+	if masked {
+		if _, err := io.ReadFull(c.reader, mask); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// Close sends a close frame and closes the connection.
+func (c *wsConn) Close() error {
+	return c.close(1000)
+}
+
+// close sends a close frame with the status code and closes the connection.
+func (c *wsConn) close(code uint16) error {
+	var err error
+	c.closeOnce.Do(func() {
+		_ = c.writeFrame(wsOpClose, binary.BigEndian.AppendUint16(nil, code))
+		err = c.rwc.Close()
+	})
+
+	return err
+}
+
+func (c *Client) Hero(ctx context.Context, req *HeroRequest) (*HeroResponse, error) {
+	var resp HeroResponse
+
+	query := `query Hero ($episode: Episode) {
+  hero(episode: $episode) {
+    __typename
+    id
+    name
+    friends {
+      name
+    }
+    ... on Droid {
+      primaryFunction
+    }
+    ... on Human {
+      homePlanet
+    }
+  }
+}
+`
+
+	hasData, err := c.do(ctx, query, req, &resp)
+	if err != nil {
+		if hasData && c.partialData {
+			return &resp, err
+		}
+
+		return nil, err
+	}
+
+	return &resp, nil
+}
+func (c *Client) HeroName(ctx context.Context, req *HeroNameRequest) (*HeroNameResponse, error) {
+	var resp HeroNameResponse
+
+	query := `query HeroName ($episode: Episode) {
+  hero(episode: $episode) {
+    ... CharacterName
+  }
+}
+fragment CharacterName on Character {
+  name
+}
+`
+
+	hasData, err := c.do(ctx, query, req, &resp)
+	if err != nil {
+		if hasData && c.partialData {
+			return &resp, err
+		}
+
+		return nil, err
+	}
+
+	return &resp, nil
+}
+func (c *Client) HeroAppearances(ctx context.Context, req *HeroAppearancesRequest) (*HeroAppearancesResponse, error) {
+	var resp HeroAppearancesResponse
+
+	query := `query HeroAppearances ($episode: Episode) {
+  hero(episode: $episode) {
+    id
+    appearsIn
+  }
+}
+`
+
+	hasData, err := c.do(ctx, query, req, &resp)
+	if err != nil {
+		if hasData && c.partialData {
+			return &resp, err
+		}
+
+		return nil, err
+	}
+
+	return &resp, nil
+}
+func (c *Client) HeroFriends(ctx context.Context, req *HeroFriendsRequest) (*HeroFriendsResponse, error) {
+	var resp HeroFriendsResponse
+
+	query := `query HeroFriends ($episode: Episode) {
+  hero(episode: $episode) {
+    ... CharacterName
+    ... CharacterFriends
+    friends {
+      id
+    }
+  }
+}
+fragment CharacterName on Character {
+  name
+}
+fragment CharacterFriends on Character {
+  friends {
+    name
+  }
+}
+`
+
+	hasData, err := c.do(ctx, query, req, &resp)
+	if err != nil {
+		if hasData && c.partialData {
+			return &resp, err
+		}
+
+		return nil, err
+	}
+
+	return &resp, nil
+}
+func (c *Client) CreateReview(ctx context.Context, req *CreateReviewRequest) (*CreateReviewResponse, error) {
+	var resp CreateReviewResponse
+
+	query := `mutation CreateReview ($episode: Episode, $review: ReviewInput!) {
+  createReview(episode: $episode, review: $review) {
+    episode
+    stars
+    commentary
+  }
+}
+`
+
+	hasData, err := c.do(ctx, query, req, &resp)
+	if err != nil {
+		if hasData && c.partialData {
+			return &resp, err
+		}
+
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ReviewAdded starts the subscription, the responses are sent on the returned
+// channel until ctx is cancelled or the server completes the subscription.
+func (c *Client) ReviewAdded(ctx context.Context, req *ReviewAddedRequest) (<-chan SubscriptionEvent[ReviewAddedResponse], error) {
+	query := `subscription ReviewAdded ($episode: Episode) {
+  reviewAdded(episode: $episode) {
+    stars
+    commentary
+  }
+}
+`
+
+	return subscribe[ReviewAddedResponse](ctx, c, query, req)
+}
+func (c *Client) Search(ctx context.Context, req *SearchRequest) (*SearchResponse, error) {
+	var resp SearchResponse
+
+	query := `query Search ($text: String!) {
+  search(text: $text) {
+    __typename
+    ... on Character {
+      name
+    }
+    ... on Starship {
+      name
+      length
+    }
+  }
+}
+`
+
+	hasData, err := c.do(ctx, query, req, &resp)
+	if err != nil {
+		if hasData && c.partialData {
+			return &resp, err
+		}
+
+		return nil, err
+	}
+
+	return &resp, nil
+}
@@ -0,0 +1,271 @@
+package starwars
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type Character struct {
+	Id        string      `json:"id"`
+	Name      string      `json:"name"`
+	Friends   []Character `json:"friends,omitempty"`
+	AppearsIn []Episode   `json:"appearsIn"`
+}
+type Droid struct {
+	Id              string      `json:"id"`
+	Name            string      `json:"name"`
+	Friends         []Character `json:"friends,omitempty"`
+	AppearsIn       []Episode   `json:"appearsIn"`
+	PrimaryFunction string      `json:"primaryFunction,omitempty"`
+}
+type Episode string
+
+const (
+	NEWHOPE Episode = "NEWHOPE"
+	EMPIRE  Episode = "EMPIRE"
+	JEDI    Episode = "JEDI"
+)
+
+type Human struct {
+	Id         string      `json:"id"`
+	Name       string      `json:"name"`
+	Friends    []Character `json:"friends,omitempty"`
+	AppearsIn  []Episode   `json:"appearsIn"`
+	HomePlanet string      `json:"homePlanet,omitempty"`
+	Height     float64     `json:"height,omitempty"`
+}
+type Review struct {
+	Episode    Episode `json:"episode,omitempty"`
+	Stars      int     `json:"stars"`
+	Commentary string  `json:"commentary,omitempty"`
+}
+type ReviewInput struct {
+	Stars      int    `json:"stars"`
+	Commentary string `json:"commentary,omitempty"`
+}
+type SearchResult struct {
+}
+type Starship struct {
+	Id     string  `json:"id"`
+	Name   string  `json:"name"`
+	Length float64 `json:"length,omitempty"`
+}
+type CharacterNameFragment struct {
+	Name string `json:"name"`
+}
+type CharacterFriendsFragment struct {
+	Friends []CharacterFriendsFragmentFriends `json:"friends,omitempty"`
+}
+type CharacterFriendsFragmentFriends struct {
+	Name string `json:"name"`
+}
+type HeroRequest struct {
+	Episode Episode `json:"episode,omitempty"`
+}
+type HeroResponse struct {
+	Hero HeroResponseHeroValue `json:"hero,omitempty"`
+}
+type HeroResponseHeroHumanFriends struct {
+	Name string `json:"name"`
+}
+
+// HeroResponseHero is implemented by the possible types of Character.
+type HeroResponseHero interface {
+	isHeroResponseHero()
+	GetTypename() string
+}
+type HeroResponseHeroHuman struct {
+	Typename   string                         `json:"__typename"`
+	Id         string                         `json:"id"`
+	Name       string                         `json:"name"`
+	Friends    []HeroResponseHeroHumanFriends `json:"friends,omitempty"`
+	HomePlanet string                         `json:"homePlanet,omitempty"`
+}
+
+func (HeroResponseHeroHuman) isHeroResponseHero()   {}
+func (v HeroResponseHeroHuman) GetTypename() string { return v.Typename }
+
+type HeroResponseHeroDroid struct {
+	Typename        string                         `json:"__typename"`
+	Id              string                         `json:"id"`
+	Name            string                         `json:"name"`
+	Friends         []HeroResponseHeroHumanFriends `json:"friends,omitempty"`
+	PrimaryFunction string                         `json:"primaryFunction,omitempty"`
+}
+
+func (HeroResponseHeroDroid) isHeroResponseHero()   {}
+func (v HeroResponseHeroDroid) GetTypename() string { return v.Typename }
+
+// HeroResponseHeroValue holds a HeroResponseHero decoded according to its __typename.
+type HeroResponseHeroValue struct {
+	Value HeroResponseHero
+}
+
+func (v *HeroResponseHeroValue) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		v.Value = nil
+		return nil
+	}
+	var t struct {
+		Typename string `json:"__typename"`
+	}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	switch t.Typename {
+	case "Human":
+		var value HeroResponseHeroHuman
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		v.Value = value
+	case "Droid":
+		var value HeroResponseHeroDroid
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		v.Value = value
+	default:
+		return fmt.Errorf("unexpected __typename %q for HeroResponseHero", t.Typename)
+	}
+	return nil
+}
+func (v HeroResponseHeroValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.Value)
+}
+
+type HeroNameRequest struct {
+	Episode Episode `json:"episode,omitempty"`
+}
+type HeroNameResponse struct {
+	Hero HeroNameResponseHero `json:"hero,omitempty"`
+}
+type HeroNameResponseHero struct {
+	CharacterNameFragment
+}
+type HeroAppearancesRequest struct {
+	Episode Episode `json:"episode,omitempty"`
+}
+type HeroAppearancesResponse struct {
+	Hero HeroAppearancesResponseHero `json:"hero,omitempty"`
+}
+type HeroAppearancesResponseHero struct {
+	Id        string    `json:"id"`
+	AppearsIn []Episode `json:"appearsIn"`
+}
+type HeroFriendsRequest struct {
+	Episode Episode `json:"episode,omitempty"`
+}
+type HeroFriendsResponse struct {
+	Hero HeroFriendsResponseHero `json:"hero,omitempty"`
+}
+type HeroFriendsResponseHero struct {
+	CharacterNameFragment
+	Friends []HeroFriendsResponseHeroFriends `json:"friends,omitempty"`
+}
+type HeroFriendsResponseHeroFriends struct {
+	Name string `json:"name"`
+	Id   string `json:"id"`
+}
+type CreateReviewRequest struct {
+	Episode Episode     `json:"episode,omitempty"`
+	Review  ReviewInput `json:"review"`
+}
+type CreateReviewResponse struct {
+	CreateReview CreateReviewResponseCreateReview `json:"createReview,omitempty"`
+}
+type CreateReviewResponseCreateReview struct {
+	Episode    Episode `json:"episode,omitempty"`
+	Stars      int     `json:"stars"`
+	Commentary string  `json:"commentary,omitempty"`
+}
+type ReviewAddedRequest struct {
+	Episode Episode `json:"episode,omitempty"`
+}
+type ReviewAddedResponse struct {
+	ReviewAdded ReviewAddedResponseReviewAdded `json:"reviewAdded,omitempty"`
+}
+type ReviewAddedResponseReviewAdded struct {
+	Stars      int    `json:"stars"`
+	Commentary string `json:"commentary,omitempty"`
+}
+type SearchRequest struct {
+	Text string `json:"text"`
+}
+type SearchResponse struct {
+	Search []SearchResponseSearchValue `json:"search,omitempty"`
+}
+
+// SearchResponseSearch is implemented by the possible types of SearchResult.
+type SearchResponseSearch interface {
+	isSearchResponseSearch()
+	GetTypename() string
+}
+type SearchResponseSearchHuman struct {
+	Typename string `json:"__typename"`
+	Name     string `json:"name"`
+}
+
+func (SearchResponseSearchHuman) isSearchResponseSearch() {}
+func (v SearchResponseSearchHuman) GetTypename() string   { return v.Typename }
+
+type SearchResponseSearchDroid struct {
+	Typename string `json:"__typename"`
+	Name     string `json:"name"`
+}
+
+func (SearchResponseSearchDroid) isSearchResponseSearch() {}
+func (v SearchResponseSearchDroid) GetTypename() string   { return v.Typename }
+
+type SearchResponseSearchStarship struct {
+	Typename string  `json:"__typename"`
+	Name     string  `json:"name"`
+	Length   float64 `json:"length,omitempty"`
+}
+
+func (SearchResponseSearchStarship) isSearchResponseSearch() {}
+func (v SearchResponseSearchStarship) GetTypename() string   { return v.Typename }
+
+// SearchResponseSearchValue holds a SearchResponseSearch decoded according to its __typename.
+type SearchResponseSearchValue struct {
+	Value SearchResponseSearch
+}
+
+func (v *SearchResponseSearchValue) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		v.Value = nil
+		return nil
+	}
+	var t struct {
+		Typename string `json:"__typename"`
+	}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	switch t.Typename {
+	case "Human":
+		var value SearchResponseSearchHuman
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		v.Value = value
+	case "Droid":
+		var value SearchResponseSearchDroid
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		v.Value = value
+	case "Starship":
+		var value SearchResponseSearchStarship
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		v.Value = value
+	default:
+		return fmt.Errorf("unexpected __typename %q for SearchResponseSearch", t.Typename)
+	}
+	return nil
+}
+func (v SearchResponseSearchValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.Value)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/stefanprifti/gqlclientgen"
+)
+
+// defaultWatchInterval is how often watch polls the operation files
+const defaultWatchInterval = 500 * time.Millisecond
+
+// fileState is what watch compares to detect a changed file
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// watchServices generates the services, then polls their operation files and
+// regenerates the model and client of a service when its files change. The
+// schema is resolved once, it is not introspected again on changes. It runs
+// until ctx is done and reports whether the first generation of every service
+// succeeded.
+func watchServices(ctx context.Context, services []gqlclientgen.Service, interval time.Duration, log *logger, stderr io.Writer) bool {
+	snapshots := make([]map[string]fileState, len(services))
+	ok := true
+
+	for i := range services {
+		s := &services[i]
+
+		snapshots[i], _ = operationsSnapshot(s)
+
+		err := runCommand(ctx, command{name: "generate", run: generateService}, s, log)
+		if err != nil {
+			printError(stderr, s, err)
+			ok = false
+		}
+	}
+
+	log.Infof("watching operations, press Ctrl+C to stop")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ok
+		case <-ticker.C:
+		}
+
+		for i := range services {
+			s := &services[i]
+
+			snapshot, err := operationsSnapshot(s)
+			if err != nil {
+				printError(stderr, s, err)
+				continue
+			}
+
+			if sameSnapshot(snapshots[i], snapshot) {
+				continue
+			}
+			snapshots[i] = snapshot
+
+			log.Debugf("operations of service %s changed", s.Name)
+
+			// the schema was resolved by the first generation, a schema
+			// which failed to resolve is retried
+			run := regenerateService
+			if s.SchemaDoc == nil {
+				run = generateService
+			}
+
+			if err := runCommand(ctx, command{name: "regenerate", run: run}, s, log); err != nil {
+				printError(stderr, s, err)
+			}
+		}
+	}
+}
+
+// regenerateService generates the model and client of the service with the
+// schema already resolved
+func regenerateService(ctx context.Context, s *gqlclientgen.Service, log *logger) error {
+	if err := resolveOperations(s, log); err != nil {
+		return err
+	}
+
+	if err := s.GenerateModelFile(ctx); err != nil {
+		return err
+	}
+
+	if err := s.GenerateClientFile(); err != nil {
+		return err
+	}
+
+	log.Infof("regenerated client for service %s in %s", s.Name, s.ClientFolder)
+
+	return nil
+}
+
+// operationsSnapshot returns the state of the operation files of the service
+func operationsSnapshot(s *gqlclientgen.Service) (map[string]fileState, error) {
+	files, err := s.OperationFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make(map[string]fileState, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			// the file was removed since it was listed
+			continue
+		}
+
+		snapshot[file] = fileState{
+			modTime: info.ModTime(),
+			size:    info.Size(),
+		}
+	}
+
+	return snapshot, nil
+}
+
+func sameSnapshot(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for file, state := range a {
+		other, ok := b[file]
+		if !ok || !other.modTime.Equal(state.modTime) || other.size != state.size {
+			return false
+		}
+	}
+
+	return true
+}
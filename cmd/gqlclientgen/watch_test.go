@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stefanprifti/gqlclientgen"
+)
+
+// syncBuffer is a bytes.Buffer safe to write from the watch goroutine
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWatchServices(t *testing.T) {
+	dir := t.TempDir()
+	operations := filepath.Join(dir, "gql")
+	if err := os.Mkdir(operations, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeOperation := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(operations, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeOperation("hero.graphql", "query Hero { hero { name } }")
+
+	services := []gqlclientgen.Service{{
+		Name:             "Star Wars API",
+		Package:          "starwars",
+		SchemaFile:       "testdata/starwars.graphql",
+		OperationsFolder: operations,
+		ClientFolder:     filepath.Join(dir, "pkg"),
+	}}
+
+	var stdout, stderr syncBuffer
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		watchServices(ctx, services, 10*time.Millisecond, &logger{out: &stdout}, &stderr)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timeout waiting for %s, stdout: %s, stderr: %s", what, stdout.String(), stderr.String())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	clientContains := func(s string) func() bool {
+		return func() bool {
+			client, _ := os.ReadFile(filepath.Join(dir, "pkg", gqlclientgen.ClientFileName))
+			return strings.Contains(string(client), s)
+		}
+	}
+
+	waitFor("the first generation", clientContains("func (c *Client) Hero("))
+
+	writeOperation("search.graphql", `query Search { search(text: "r2") { __typename } }`)
+	waitFor("the new operation", clientContains("func (c *Client) Search("))
+
+	// an invalid operation is reported and the watch goes on
+	writeOperation("invalid.graphql", "query Invalid { hero { foo } }")
+	waitFor("the error", func() bool {
+		return strings.Contains(stderr.String(), `invalid.graphql:1:24: Cannot query field "foo" on type "Character".`)
+	})
+
+	if err := os.Remove(filepath.Join(operations, "invalid.graphql")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("the regeneration", func() bool {
+		return strings.Count(stdout.String(), "regenerated client for service Star Wars API") == 2
+	})
+}
+
+func TestWatchServicesFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	services := []gqlclientgen.Service{{
+		Name:             "Star Wars API",
+		Package:          "starwars",
+		SchemaFile:       filepath.Join(dir, "missing.graphql"),
+		OperationsFolder: dir,
+		ClientFolder:     filepath.Join(dir, "pkg"),
+	}}
+
+	// the watch stops right after the first generation
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var stdout, stderr bytes.Buffer
+	if watchServices(ctx, services, 10*time.Millisecond, &logger{out: &stdout}, &stderr) {
+		t.Fatalf("expected the failed generation to be reported")
+	}
+
+	if !strings.Contains(stderr.String(), "missing.graphql") {
+		t.Errorf("expected the error to be printed, got %q", stderr.String())
+	}
+}
@@ -0,0 +1,189 @@
+package gqlclientgen
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v2"
+)
+
+var ErrConfigNotFound = fmt.Errorf("config file not found")
+
+// Config is the configuration for gqlclientgen
+type Config struct {
+	Version  int             `yaml:"version"`
+	Services []ServiceConfig `yaml:"services"`
+}
+
+// ServiceConfig is the configuration of a service
+type ServiceConfig struct {
+	Name         string            `yaml:"name"`
+	Package      string            `yaml:"package"`
+	URL          string            `yaml:"url"`
+	Schema       string            `yaml:"schema"`
+	SchemaTTL    string            `yaml:"schemaTTL"`
+	SortSchema   string            `yaml:"sortSchema"`
+	TypeRefDepth int               `yaml:"typeRefDepth"`
+	Nullable     string            `yaml:"nullable"`
+	Naming       string            `yaml:"naming"`
+	Scalars      map[string]string `yaml:"scalars"`
+	Headers      map[string]string `yaml:"headers"`
+	BasicAuth    BasicAuthConfig   `yaml:"basicAuth"`
+	TLS          TLSConfig         `yaml:"tls"`
+	Operations   OperationsConfig  `yaml:"operations"`
+	Client       ClientConfig      `yaml:"client"`
+}
+
+// OperationsConfig is where the operations of a service are read from
+type OperationsConfig struct {
+	Root    string   `yaml:"root"`
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+}
+
+// ClientConfig is where the client of a service is generated
+type ClientConfig struct {
+	Root string `yaml:"root"`
+}
+
+// BasicAuthConfig is the basic auth used to introspect a service
+type BasicAuthConfig struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// TLSConfig is the TLS configuration used to introspect a service
+type TLSConfig struct {
+	// CA is the PEM file of the certificate authority used to verify the server
+	CA string `yaml:"ca"`
+	// Cert and Key are the PEM files of the client certificate
+	Cert               string `yaml:"cert"`
+	Key                string `yaml:"key"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+}
+
+func LoadConfig(filepath string) (Config, error) {
+	var config Config
+
+	// read gqlclientgen.yml in the root directory
+	f, err := os.OpenFile(filepath, os.O_RDONLY, 0644)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return config, fmt.Errorf("%w: %s", ErrConfigNotFound, filepath)
+		}
+
+		return config, err
+	}
+	defer f.Close()
+
+	// read the file
+	body, err := io.ReadAll(f)
+	if err != nil {
+		return config, err
+	}
+
+	err = yaml.Unmarshal(body, &config)
+	if err != nil {
+		return config, fmt.Errorf("failed to parse config %s: %w", filepath, err)
+	}
+	return config, nil
+}
+
+var envVarRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces the ${ENV_VAR} references in s with the value of the environment variables
+func expandEnv(s string) (string, error) {
+	var err error
+
+	expanded := envVarRegexp.ReplaceAllStringFunc(s, func(match string) string {
+		name := envVarRegexp.FindStringSubmatch(match)[1]
+
+		value, ok := os.LookupEnv(name)
+		if !ok && err == nil {
+			err = fmt.Errorf("environment variable %s is not set", name)
+		}
+
+		return value
+	})
+
+	return expanded, err
+}
+
+// httpHeaders returns the headers sent to introspect a service, with the environment variables expanded
+func httpHeaders(headers http.Header, basicAuth BasicAuthConfig) (http.Header, error) {
+	h := http.Header{}
+
+	for key, values := range headers {
+		for _, value := range values {
+			expanded, err := expandEnv(value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to expand header %s: %w", key, err)
+			}
+
+			h.Add(key, expanded)
+		}
+	}
+
+	if basicAuth.Username != "" || basicAuth.Password != "" {
+		username, err := expandEnv(basicAuth.Username)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand basic auth username: %w", err)
+		}
+
+		password, err := expandEnv(basicAuth.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand basic auth password: %w", err)
+		}
+
+		credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		h.Set("Authorization", "Basic "+credentials)
+	}
+
+	return h, nil
+}
+
+// HTTPClient returns the client used to introspect a service, nil when no TLS option is set
+func (c TLSConfig) HTTPClient() (*http.Client, error) {
+	if c.CA == "" && c.Cert == "" && c.Key == "" && !c.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CA != "" {
+		ca, err := os.ReadFile(c.CA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA %s: %w", c.CA, err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate found in CA %s", c.CA)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.Cert != "" || c.Key != "" {
+		cert, err := tls.LoadX509KeyPair(c.Cert, c.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Transport: transport}, nil
+}
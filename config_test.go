@@ -0,0 +1,121 @@
+package gqlclientgen
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("GQLCLIENTGEN_TOKEN", "secret")
+
+	cases := []struct {
+		name     string
+		value    string
+		expected string
+		err      bool
+	}{
+		{
+			name:     "variable",
+			value:    "Bearer ${GQLCLIENTGEN_TOKEN}",
+			expected: "Bearer secret",
+		},
+		{
+			name:     "no variable",
+			value:    "pa$$word",
+			expected: "pa$$word",
+		},
+		{
+			name:  "missing variable",
+			value: "Bearer ${GQLCLIENTGEN_MISSING}",
+			err:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := expandEnv(tc.value)
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tc.expected {
+				t.Fatalf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestHTTPHeaders(t *testing.T) {
+	t.Setenv("GQLCLIENTGEN_PASSWORD", "secret")
+
+	headers, err := httpHeaders(http.Header{"X-Api-Key": {"key"}}, BasicAuthConfig{
+		Username: "user",
+		Password: "${GQLCLIENTGEN_PASSWORD}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := headers.Get("X-Api-Key"); got != "key" {
+		t.Fatalf("expected key, got %s", got)
+	}
+
+	// base64 of user:secret
+	if got := headers.Get("Authorization"); got != "Basic dXNlcjpzZWNyZXQ=" {
+		t.Fatalf("expected basic auth, got %s", got)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), ConfigFileName)
+	err := os.WriteFile(configFile, []byte(`
+version: 1
+services:
+  - name: Star Wars API
+    package: starwars
+    schema: starwars.graphql
+    nullable: pointer
+    operations:
+      root: gql/starwars
+      exclude: ["**/*_test.graphql"]
+    client:
+      root: pkg/starwars
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Config{
+		Version: 1,
+		Services: []ServiceConfig{{
+			Name:     "Star Wars API",
+			Package:  "starwars",
+			Schema:   "starwars.graphql",
+			Nullable: "pointer",
+			Operations: OperationsConfig{
+				Root:    "gql/starwars",
+				Exclude: []string{"**/*_test.graphql"},
+			},
+			Client: ClientConfig{Root: "pkg/starwars"},
+		}},
+	}
+
+	if !reflect.DeepEqual(config, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, config)
+	}
+}
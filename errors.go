@@ -0,0 +1,60 @@
+package gqlclientgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// OperationErrors are the parse and validation errors of the operation files
+// of a service, printed like compiler errors, one per line.
+type OperationErrors gqlerror.List
+
+func (e OperationErrors) Error() string {
+	lines := make([]string, 0, len(e))
+	for _, err := range e {
+		lines = append(lines, formatOperationError(err))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// sort orders the errors by file, line and column.
+func (e OperationErrors) sort() {
+	sort.SliceStable(e, func(i, j int) bool {
+		fi, li, ci := errorPosition(e[i])
+		fj, lj, cj := errorPosition(e[j])
+		if fi != fj {
+			return fi < fj
+		}
+		if li != lj {
+			return li < lj
+		}
+		return ci < cj
+	})
+}
+
+// formatOperationError formats the error as path:line:col: message
+func formatOperationError(err *gqlerror.Error) string {
+	file, line, col := errorPosition(err)
+	if file == "" {
+		file = "<unknown>"
+	}
+
+	if line == 0 {
+		return fmt.Sprintf("%s: %s", file, err.Message)
+	}
+
+	return fmt.Sprintf("%s:%d:%d: %s", file, line, col, err.Message)
+}
+
+func errorPosition(err *gqlerror.Error) (string, int, int) {
+	file, _ := err.Extensions["file"].(string)
+	if len(err.Locations) == 0 {
+		return file, 0, 0
+	}
+
+	return file, err.Locations[0].Line, err.Locations[0].Column
+}
@@ -0,0 +1,246 @@
+package gqlclientgen
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/stefanprifti/gqlclientgen/gen"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+//go:embed client.go.tmpl
+var clientFileTmpl string
+
+// The names of the files generated in the client folder of a service, and
+// the default name of the config file
+const (
+	ClientFileName        = "client.go"
+	ModelFileName         = "model.go"
+	SchemaFileName        = "schema.graphql"
+	IntrospectionFileName = "schema.introspect.json"
+	ConfigFileName        = "gqlclientgen.yml"
+)
+
+type ClientMethod struct {
+	Name     string
+	Query    string
+	Request  string
+	Response string
+	Type     string
+}
+
+// GeneratedFile is a file generated for a service
+type GeneratedFile struct {
+	Path    string
+	Content []byte
+}
+
+// GenerateFiles generates the files of the service in memory, in the order
+// they are written: introspection result, schema, model and client
+func (s *Service) GenerateFiles(ctx context.Context) ([]GeneratedFile, error) {
+	var files []GeneratedFile
+
+	if file, ok := s.introspectionFile(); ok {
+		files = append(files, file)
+	}
+
+	files = append(files, s.schemaFile())
+
+	model, err := s.modelFile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, model)
+
+	client, err := s.clientFile()
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, client)
+
+	return files, nil
+}
+
+// Write writes the file, creating its folder if needed
+func (f GeneratedFile) Write() error {
+	if err := writeFile(f.Path, f.Content); err != nil {
+		return fmt.Errorf("failed to write %s: %w", f.Path, err)
+	}
+
+	return nil
+}
+
+// introspectionFile returns the introspection file of the service, if it is
+// written
+func (s *Service) introspectionFile() (GeneratedFile, bool) {
+	// there is no introspection result until the schema is resolved, and a
+	// cached one is left untouched so its age is kept
+	if s.SchemaJSON == nil || s.SchemaCached {
+		return GeneratedFile{}, false
+	}
+
+	return GeneratedFile{Path: filepath.Join(s.ClientFolder, IntrospectionFileName), Content: s.SchemaJSON}, true
+}
+
+func (s *Service) schemaFile() GeneratedFile {
+	return GeneratedFile{Path: filepath.Join(s.ClientFolder, SchemaFileName), Content: []byte(s.SchemaContent)}
+}
+
+func (s *Service) modelFile(ctx context.Context) (GeneratedFile, error) {
+	model, err := s.ModelFile(ctx)
+	if err != nil {
+		return GeneratedFile{}, err
+	}
+
+	return GeneratedFile{Path: filepath.Join(s.ClientFolder, ModelFileName), Content: model}, nil
+}
+
+func (s *Service) clientFile() (GeneratedFile, error) {
+	client, err := s.ClientFile()
+	if err != nil {
+		return GeneratedFile{}, err
+	}
+
+	return GeneratedFile{Path: filepath.Join(s.ClientFolder, ClientFileName), Content: client}, nil
+}
+
+// GenerateIntrospectionFile generates the introspection file for the service
+func (s *Service) GenerateIntrospectionFile() error {
+	file, ok := s.introspectionFile()
+	if !ok {
+		return nil
+	}
+
+	return file.Write()
+}
+
+// GenerateSchemaFile generates the schema file for the service
+func (s *Service) GenerateSchemaFile() error {
+	return s.schemaFile().Write()
+}
+
+// GenerateModelFile generates the model file for the service
+func (s *Service) GenerateModelFile(ctx context.Context) error {
+	file, err := s.modelFile(ctx)
+	if err != nil {
+		return err
+	}
+
+	return file.Write()
+}
+
+// ModelFile returns the formatted content of the model file
+func (s *Service) ModelFile(ctx context.Context) ([]byte, error) {
+	types, err := gen.GenerateTypes(ctx, s.SchemaDoc, s.OperationsDoc, gen.Options{
+		PackageName: s.Package,
+		Nullable:    s.Nullable,
+		Naming:      s.Naming,
+		Scalars:     s.Scalars,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate types: %w", err)
+	}
+
+	types, err = format.Source(types)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format model file: %w", err)
+	}
+
+	return types, nil
+}
+
+// GenerateClientFile generates the client file for the service
+func (s *Service) GenerateClientFile() error {
+	file, err := s.clientFile()
+	if err != nil {
+		return err
+	}
+
+	return file.Write()
+}
+
+// ClientFile returns the formatted content of the client file
+func (s *Service) ClientFile() ([]byte, error) {
+	// Get the template
+	tmpl := template.Must(template.New("template").Parse(clientFileTmpl))
+
+	// Create data for template
+	methods := make([]ClientMethod, 0, len(s.OperationsDoc.Operations))
+	hasSubscriptions := false
+
+	for _, op := range s.OperationsDoc.Operations {
+		if op.Operation == ast.Subscription {
+			hasSubscriptions = true
+		}
+
+		methods = append(methods, ClientMethod{
+			Name:     op.Name,
+			Query:    s.operationQuery(op),
+			Request:  fmt.Sprintf("%sRequest", op.Name),
+			Response: fmt.Sprintf("%sResponse", op.Name),
+			Type:     string(op.Operation),
+		})
+	}
+
+	// Execute the template
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, map[string]interface{}{
+		"PackageName":      s.Package,
+		"Methods":          methods,
+		"HasSubscriptions": hasSubscriptions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format client file: %w", err)
+	}
+
+	return code, nil
+}
+
+func writeFile(filePath string, body []byte) error {
+	f, err := openFile(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(body)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// openFile opens the file for writing, creating its folder if needed
+func openFile(filePath string) (*os.File, error) {
+	f, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// create the file even if the directories don't exist
+			if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+				return nil, err
+			}
+
+			f, err = os.Create(filePath)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"path"
 	"sort"
 	"strings"
 
@@ -12,9 +13,90 @@ import (
 
 const defaultPackageName = "main"
 
+// NullableStrategy defines how nullable GraphQL types are represented in Go
+type NullableStrategy string
+
+const (
+	// NullableZero uses the plain Go type, null is decoded as the zero value
+	NullableZero NullableStrategy = "zero"
+	// NullablePointer uses a pointer for nullable types, lists are left as slices
+	NullablePointer NullableStrategy = "pointer"
+	// NullableOptional uses the generated Optional type which tells null, absent and a value apart
+	NullableOptional NullableStrategy = "optional"
+)
+
+// NamingStrategy defines how the types of the nested selections are named
+type NamingStrategy string
+
+const (
+	// NamingPath names a type after the path of its field from the response
+	// or fragment, e.g. CountryResponseCountryLanguages
+	NamingPath NamingStrategy = "path"
+	// NamingType names a type after the response or fragment and the GraphQL
+	// type of its field, e.g. CountryResponseLanguage
+	NamingType NamingStrategy = "type"
+)
+
 type Options struct {
 	// PackageName is the name of the package to generate
 	PackageName string
+	// Nullable is the strategy used for nullable types, defaults to NullableZero
+	Nullable NullableStrategy
+	// Scalars maps a scalar name to the Go type used for it. The type is either
+	// a builtin type like int64 or an import path followed by the type name,
+	// like time.Time or github.com/shopspring/decimal.Decimal
+	Scalars map[string]string
+	// Naming is the strategy used to name the types of the nested
+	// selections, defaults to NamingPath
+	Naming NamingStrategy
+}
+
+// generator holds the state shared while generating the types of a file
+type generator struct {
+	schema  *ast.Schema
+	options Options
+
+	// imports are the packages used by the generated types, mapped to their alias
+	imports map[string]string
+	// packages maps the names the imported packages are used with to their
+	// import path, so two packages are not used with the same name
+	packages map[string]string
+	// deferred holds the named types generated while printing a type,
+	// they are written once the current type is done
+	deferred bytes.Buffer
+
+	// root is the name of the response or fragment being generated
+	root string
+	// names are the generated type names, a name is numbered when taken
+	names map[string]bool
+	// selections maps the root, the GraphQL type and the fields of a
+	// generated selection type to its name, so identical selections of a
+	// response or fragment share a type
+	selections map[string]string
+	// optional holds the names of the Optional type and of its Some and Null
+	// constructors, they are numbered when the schema uses the names
+	optional optionalNames
+}
+
+type optionalNames struct {
+	typ  string
+	some string
+	null string
+}
+
+func newGenerator(schema *ast.Schema, options Options) *generator {
+	return &generator{
+		schema:  schema,
+		options: options,
+		imports: make(map[string]string),
+		// the packages imported by the generated code itself
+		packages: map[string]string{
+			"json": "encoding/json",
+			"fmt":  "fmt",
+		},
+		names:      make(map[string]bool),
+		selections: make(map[string]string),
+	}
 }
 
 // GenerateTypes generates the types for the given schema and query
@@ -30,24 +112,80 @@ func GenerateTypes(ctx context.Context, schema *ast.Schema, query *ast.QueryDocu
 			packageName = defaultPackageName
 		}
 
+		switch options.Nullable {
+		case "", NullableZero, NullablePointer, NullableOptional:
+		default:
+			return nil, fmt.Errorf("unknown nullable strategy %q", options.Nullable)
+		}
+
+		switch options.Naming {
+		case "", NamingPath, NamingType:
+		default:
+			return nil, fmt.Errorf("unknown naming strategy %q", options.Naming)
+		}
+
+		g := newGenerator(schema, options)
+
+		// the names of the schema types and enum values are kept, the
+		// generated names are chosen around them
+		g.reserveSchemaNames()
+		if g.options.Nullable == NullableOptional {
+			g.optional = optionalNames{
+				typ:  g.reserveName("Optional"),
+				some: g.reserveName("Some"),
+				null: g.reserveName("Null"),
+			}
+		}
+
 		// generate the types from the schema
-		schemaTypes, err := GenerateTypesFromSchema(packageName, schema)
+		var body bytes.Buffer
+		err := g.generateSchemaTypes(&body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate schema types: %w", err)
 		}
 
 		// generate the types from the query
-		queryTypes := GenerateTypesFromOperation(query)
+		g.generateOperationTypes(query, &body)
+
+		if g.options.Nullable == NullableOptional {
+			g.generateOptionalType(&body)
+		}
 
 		// combine the types
 		var b bytes.Buffer
-		b.Write(schemaTypes)
-		b.Write(queryTypes)
+		g.writeHeader(packageName, &b)
+		b.Write(body.Bytes())
 
 		return b.Bytes(), nil
 	}
 }
 
+// writeHeader writes the package clause and the imports used by the generated types
+func (g *generator) writeHeader(packageName string, b *bytes.Buffer) {
+	// write the package name
+	b.WriteString("package " + packageName + "\n\n")
+
+	if len(g.imports) == 0 {
+		return
+	}
+
+	imports := make([]string, 0, len(g.imports))
+	for i := range g.imports {
+		imports = append(imports, i)
+	}
+	sort.Strings(imports)
+
+	b.WriteString("import (\n")
+	for _, i := range imports {
+		if alias := g.imports[i]; alias != "" {
+			b.WriteString("\t" + alias + " \"" + i + "\"\n")
+		} else {
+			b.WriteString("\t\"" + i + "\"\n")
+		}
+	}
+	b.WriteString(")\n\n")
+}
+
 func getOrderedTypes(schema *ast.Schema) []*ast.Definition {
 	types := make([]*ast.Definition, 0, len(schema.Types))
 
@@ -66,12 +204,23 @@ func getOrderedTypes(schema *ast.Schema) []*ast.Definition {
 }
 
 func GenerateTypesFromSchema(packageName string, schema *ast.Schema) ([]byte, error) {
+	g := newGenerator(schema, Options{})
+
+	var body bytes.Buffer
+	err := g.generateSchemaTypes(&body)
+	if err != nil {
+		return nil, err
+	}
+
 	var b bytes.Buffer
+	g.writeHeader(packageName, &b)
+	b.Write(body.Bytes())
 
-	// write the package name
-	b.WriteString("package " + packageName + "\n\n")
+	return b.Bytes(), nil
+}
 
-	for _, t := range getOrderedTypes(schema) {
+func (g *generator) generateSchemaTypes(b *bytes.Buffer) error {
+	for _, t := range getOrderedTypes(g.schema) {
 		// skip the built in types
 		if t.BuiltIn {
 			continue
@@ -89,7 +238,10 @@ func GenerateTypesFromSchema(packageName string, schema *ast.Schema) ([]byte, er
 
 		switch t.Kind {
 		case ast.Scalar:
-			// todo handle scalars
+			// mapped scalars use the configured type instead
+			if _, ok := g.options.Scalars[t.Name]; ok {
+				continue
+			}
 			b.WriteString("type " + t.Name + " string\n")
 
 		case ast.Object, ast.Interface, ast.Union, ast.InputObject:
@@ -102,10 +254,18 @@ func GenerateTypesFromSchema(packageName string, schema *ast.Schema) ([]byte, er
 				}
 
 				// write the field name and type
-				printFieldDefinition(f, &b, 1)
+				g.printFieldDefinition(f, b, 1)
 			}
 
 			b.WriteString("}\n")
+
+			if t.Kind == ast.InputObject {
+				fields := make([]inputField, 0, len(t.Fields))
+				for _, f := range t.Fields {
+					fields = append(fields, inputField{name: toCammelCase(f.Name), key: f.Name, nullable: !f.Type.NonNull})
+				}
+				g.generateInputMarshaler(b, t.Name, fields)
+			}
 		case ast.Enum:
 			b.WriteString("type " + t.Name + " string\n")
 			b.WriteString("const (\n")
@@ -119,72 +279,368 @@ func GenerateTypesFromSchema(packageName string, schema *ast.Schema) ([]byte, er
 		}
 	}
 
-	return b.Bytes(), nil
+	return nil
 }
 
-func GenerateTypesFromOperation(doc *ast.QueryDocument) []byte {
-	var b bytes.Buffer
+func (g *generator) generateOperationTypes(doc *ast.QueryDocument, b *bytes.Buffer) {
+	// the names of the fragments and operations are taken before the nested
+	// types are named
+	for _, f := range doc.Fragments {
+		g.names[fragmentTypeName(f.Name)] = true
+	}
+	for _, op := range doc.Operations {
+		g.names[op.Name+"Request"] = true
+		g.names[op.Name+"Response"] = true
+	}
+
+	// Print the fragment structs, they are embedded wherever the fragment is spread
+	for _, f := range doc.Fragments {
+		g.root = fragmentTypeName(f.Name)
+		fmt.Fprintf(b, "type %s struct {\n", fragmentTypeName(f.Name))
+		g.generateResponseTypes(f.SelectionSet, b, 1, fragmentTypeName(f.Name))
+		fmt.Fprintln(b, "}")
+		g.flushDeferred(b)
+	}
 
 	for _, op := range doc.Operations {
 		// Print the request struct
-		fmt.Fprintf(&b, "type %sRequest struct {\n", op.Name)
+		fmt.Fprintf(b, "type %sRequest struct {\n", op.Name)
+		fields := make([]inputField, 0, len(op.VariableDefinitions))
 		for _, v := range op.VariableDefinitions {
 			if v.Type.NonNull {
-				fmt.Fprintf(&b, "\t%s %s `json:\"%s\"`\n", toCammelCase(v.Variable), convertGraphQLTypeToGoType(v.Type), v.Variable)
+				fmt.Fprintf(b, "\t%s %s `json:\"%s\"`\n", toCammelCase(v.Variable), g.goType(v.Type), v.Variable)
 			} else {
-				fmt.Fprintf(&b, "\t%s %s `json:\"%s,omitempty\"`\n", toCammelCase(v.Variable), convertGraphQLTypeToGoType(v.Type), v.Variable)
+				fmt.Fprintf(b, "\t%s %s `json:\"%s,omitempty\"`\n", toCammelCase(v.Variable), g.goType(v.Type), v.Variable)
 			}
+			fields = append(fields, inputField{name: toCammelCase(v.Variable), key: v.Variable, nullable: !v.Type.NonNull})
 		}
-		fmt.Fprintln(&b, "}")
+		fmt.Fprintln(b, "}")
+		g.generateInputMarshaler(b, op.Name+"Request", fields)
 
 		// Print the response struct
-		fmt.Fprintf(&b, "type %sResponse struct {\n", op.Name)
+		g.root = op.Name + "Response"
+		fmt.Fprintf(b, "type %sResponse struct {\n", op.Name)
 
 		// TODO: maybe add option to skip the first selection set?
-		generateResponseTypes(op.SelectionSet, &b, 1)
-		fmt.Fprintln(&b, "}")
+		g.generateResponseTypes(op.SelectionSet, b, 1, op.Name+"Response")
+		fmt.Fprintln(b, "}")
+		g.flushDeferred(b)
+	}
+}
+
+// flushDeferred writes the named types generated while printing the last type
+func (g *generator) flushDeferred(b *bytes.Buffer) {
+	b.Write(g.deferred.Bytes())
+	g.deferred.Reset()
+}
+
+// generateResponseTypes prints the fields of a selection set. The typeName is
+// the name of the enclosing type, used to name the types generated for nested selections.
+func (g *generator) generateResponseTypes(sel ast.SelectionSet, b *bytes.Buffer, level int, typeName string) {
+	// the inline fragments apply to the enclosing type, so their fields are flattened
+	g.generateMembers(g.mergeSelection(sel, nil), b, level, typeName)
+}
+
+// generateMembers prints the merged fields and the embedded fragments of a
+// selection set
+func (g *generator) generateMembers(members []member, b *bytes.Buffer, level int, typeName string) {
+	for _, m := range members {
+		if m.field != nil {
+			g.generateResponseField(m.field, b, level, typeName)
+			continue
+		}
+
+		// embed the fragment struct so its fields are promoted
+		fmt.Fprintf(b, "%s%s\n", strings.Repeat("\t", level), fragmentTypeName(m.fragment))
+	}
+}
+
+func (g *generator) generateResponseField(s *ast.Field, b *bytes.Buffer, level int, typeName string) {
+	// scalars and enums have no selection set
+	if len(s.SelectionSet) == 0 {
+		if s.Definition.Type.NonNull {
+			fmt.Fprintf(b, "%s%s %s `json:\"%s\"`\n", strings.Repeat("\t", level), toCammelCase(s.Alias), g.goType(s.Definition.Type), s.Alias)
+		} else {
+			fmt.Fprintf(b, "%s%s %s `json:\"%s,omitempty\"`\n", strings.Repeat("\t", level), toCammelCase(s.Alias), g.goType(s.Definition.Type), s.Alias)
+		}
+		return
+	}
+
+	prefix, suffix := g.typeWrappers(s.Definition.Type)
+
+	if def := g.schema.Types[s.Definition.Type.Name()]; isPolymorphic(def, s.SelectionSet) {
+		// the Value wrapper and the structs of the possible types are named after the interface
+		suffixes := []string{"Value"}
+		for _, t := range g.schema.GetPossibleTypes(def) {
+			suffixes = append(suffixes, toCammelCase(t.Name))
+		}
+
+		fieldTypeName := g.typeName(typeName, s, suffixes...)
+		g.generatePolymorphicTypes(fieldTypeName, def, s.SelectionSet)
+
+		if s.Definition.Type.NonNull {
+			fmt.Fprintf(b, "%s%s %s%sValue%s `json:\"%s\"`\n", strings.Repeat("\t", level), toCammelCase(s.Alias), prefix, fieldTypeName, suffix, s.Alias)
+		} else {
+			fmt.Fprintf(b, "%s%s %s%sValue%s `json:\"%s,omitempty\"`\n", strings.Repeat("\t", level), toCammelCase(s.Alias), prefix, fieldTypeName, suffix, s.Alias)
+		}
+		return
+	}
+
+	fieldTypeName := g.selectionType(typeName, s)
+
+	if s.Definition.Type.NonNull {
+		fmt.Fprintf(b, "%s%s %s%s%s `json:\"%s\"`\n", strings.Repeat("\t", level), toCammelCase(s.Alias), prefix, fieldTypeName, suffix, s.Alias)
+	} else {
+		fmt.Fprintf(b, "%s%s %s%s%s `json:\"%s,omitempty\"`\n", strings.Repeat("\t", level), toCammelCase(s.Alias), prefix, fieldTypeName, suffix, s.Alias)
+	}
+}
+
+// selectionType generates the named struct of the selection set of the field
+// and returns its name. A selection identical to one already generated for
+// the same root, with the same fields on the same GraphQL type, reuses its
+// struct. Selections of other roots are not shared, so changing an operation
+// does not rename the types of another one.
+func (g *generator) selectionType(typeName string, s *ast.Field) string {
+	// the struct is written before the types of its fields
+	mark := g.deferred.Len()
+
+	name := g.typeName(typeName, s)
+
+	var body bytes.Buffer
+	g.generateResponseTypes(s.SelectionSet, &body, 1, name)
+
+	key := g.root + "\n" + s.Definition.Type.Name() + "\n" + body.String()
+	if existing, ok := g.selections[key]; ok {
+		delete(g.names, name)
+		return existing
+	}
+	g.selections[key] = name
+
+	fields := append([]byte(nil), g.deferred.Bytes()[mark:]...)
+	g.deferred.Truncate(mark)
+
+	fmt.Fprintf(&g.deferred, "type %s struct {\n", name)
+	g.deferred.Write(body.Bytes())
+	fmt.Fprintf(&g.deferred, "}\n")
+	g.deferred.Write(fields)
+
+	return name
+}
+
+// typeName returns a free name for the type of the selection set of the
+// field, following the naming strategy. typeName is the name of the
+// enclosing type. The names derived from it with the suffixes are taken too.
+func (g *generator) typeName(typeName string, s *ast.Field, suffixes ...string) string {
+	name := typeName + toCammelCase(s.Alias)
+	if g.options.Naming == NamingType {
+		name = g.root + toCammelCase(s.Definition.Type.Name())
+	}
+
+	return g.reserveName(name, suffixes...)
+}
+
+// reserveName takes the name and the names derived from it with the
+// suffixes. The name is numbered when one of them is already taken.
+func (g *generator) reserveName(name string, suffixes ...string) string {
+	suffixes = append([]string{""}, suffixes...)
+
+	taken := func(name string) bool {
+		for _, suffix := range suffixes {
+			if g.names[name+suffix] {
+				return true
+			}
+		}
+		return false
+	}
+
+	unique := name
+	for i := 2; taken(unique); i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	for _, suffix := range suffixes {
+		g.names[unique+suffix] = true
+	}
+
+	return unique
+}
+
+// reserveSchemaNames takes the names of the schema types and enum values
+func (g *generator) reserveSchemaNames() {
+	for _, t := range g.schema.Types {
+		if t.BuiltIn {
+			continue
+		}
+
+		g.names[t.Name] = true
+		for _, v := range t.EnumValues {
+			g.names[v.Name] = true
+		}
+	}
+}
+
+// typeWrappers returns what goes around the Go type of the named type for
+// the list and nullable wrappers of typ, e.g. []* and "" for [T] with pointers.
+func (g *generator) typeWrappers(typ *ast.Type) (string, string) {
+	prefix, suffix := "", ""
+	if typ.Elem != nil {
+		prefix, suffix = g.typeWrappers(typ.Elem)
+		prefix = "[]" + prefix
+	}
+
+	if typ.NonNull {
+		return prefix, suffix
+	}
+
+	switch g.options.Nullable {
+	case NullablePointer:
+		// a nil slice already represents null
+		if typ.Elem != nil {
+			return prefix, suffix
+		}
+		return "*" + prefix, suffix
+	case NullableOptional:
+		return g.optional.typ + "[" + prefix, suffix + "]"
+	default:
+		return prefix, suffix
 	}
-	return b.Bytes()
 }
 
-func generateResponseTypes(sel ast.SelectionSet, b *bytes.Buffer, level int) {
+// goType returns the Go type of a GraphQL type
+func (g *generator) goType(typ *ast.Type) string {
+	prefix, suffix := g.typeWrappers(typ)
+
+	return prefix + g.convertGraphQLTypeToGoType(typ) + suffix
+}
+
+// generatePolymorphicTypes generates an interface for a selection on an
+// interface or union, a struct for each of its possible types and a Value
+// wrapper that decodes the right struct based on __typename.
+func (g *generator) generatePolymorphicTypes(name string, def *ast.Definition, sel ast.SelectionSet) {
+	g.imports["encoding/json"] = ""
+	g.imports["fmt"] = ""
+
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "// %s is implemented by the possible types of %s.\n", name, def.Name)
+	fmt.Fprintf(&b, "type %s interface {\n", name)
+	fmt.Fprintf(&b, "\tis%s()\n", name)
+	fmt.Fprintf(&b, "\tGetTypename() string\n")
+	fmt.Fprintf(&b, "}\n")
+
+	possibleTypes := g.schema.GetPossibleTypes(def)
+
+	for _, t := range possibleTypes {
+		variant := name + toCammelCase(t.Name)
+
+		fmt.Fprintf(&b, "type %s struct {\n", variant)
+		g.generateVariantTypes(sel, t, &b, 1, variant)
+		fmt.Fprintf(&b, "}\n")
+		fmt.Fprintf(&b, "func (%s) is%s() {}\n", variant, name)
+		fmt.Fprintf(&b, "func (v %s) GetTypename() string { return v.Typename }\n", variant)
+	}
+
+	fmt.Fprintf(&b, "// %sValue holds a %s decoded according to its __typename.\n", name, name)
+	fmt.Fprintf(&b, "type %sValue struct {\n", name)
+	fmt.Fprintf(&b, "\tValue %s\n", name)
+	fmt.Fprintf(&b, "}\n")
+
+	fmt.Fprintf(&b, "func (v *%sValue) UnmarshalJSON(data []byte) error {\n", name)
+	fmt.Fprintf(&b, "\tif string(data) == \"null\" {\n")
+	fmt.Fprintf(&b, "\t\tv.Value = nil\n")
+	fmt.Fprintf(&b, "\t\treturn nil\n")
+	fmt.Fprintf(&b, "\t}\n")
+	fmt.Fprintf(&b, "\tvar t struct {\n")
+	fmt.Fprintf(&b, "\t\tTypename string `json:\"__typename\"`\n")
+	fmt.Fprintf(&b, "\t}\n")
+	fmt.Fprintf(&b, "\tif err := json.Unmarshal(data, &t); err != nil {\n")
+	fmt.Fprintf(&b, "\t\treturn err\n")
+	fmt.Fprintf(&b, "\t}\n")
+	fmt.Fprintf(&b, "\tswitch t.Typename {\n")
+	for _, t := range possibleTypes {
+		fmt.Fprintf(&b, "\tcase \"%s\":\n", t.Name)
+		fmt.Fprintf(&b, "\t\tvar value %s%s\n", name, toCammelCase(t.Name))
+		fmt.Fprintf(&b, "\t\tif err := json.Unmarshal(data, &value); err != nil {\n")
+		fmt.Fprintf(&b, "\t\t\treturn err\n")
+		fmt.Fprintf(&b, "\t\t}\n")
+		fmt.Fprintf(&b, "\t\tv.Value = value\n")
+	}
+	fmt.Fprintf(&b, "\tdefault:\n")
+	fmt.Fprintf(&b, "\t\treturn fmt.Errorf(\"unexpected __typename %%q for %s\", t.Typename)\n", name)
+	fmt.Fprintf(&b, "\t}\n")
+	fmt.Fprintf(&b, "\treturn nil\n")
+	fmt.Fprintf(&b, "}\n")
+
+	fmt.Fprintf(&b, "func (v %sValue) MarshalJSON() ([]byte, error) {\n", name)
+	fmt.Fprintf(&b, "\treturn json.Marshal(v.Value)\n")
+	fmt.Fprintf(&b, "}\n")
+
+	g.deferred.Write(b.Bytes())
+}
+
+// generateVariantTypes prints the fields of a polymorphic selection set that
+// apply to the concrete type t.
+func (g *generator) generateVariantTypes(sel ast.SelectionSet, t *ast.Definition, b *bytes.Buffer, level int, typeName string) {
+	g.generateMembers(g.mergeSelection(sel, t), b, level, typeName)
+}
+
+// fragmentApplies reports whether a fragment with the type condition applies to the object type t
+func (g *generator) fragmentApplies(typeCondition string, t *ast.Definition) bool {
+	if typeCondition == "" || typeCondition == t.Name {
+		return true
+	}
+
+	def := g.schema.Types[typeCondition]
+	if def == nil || !def.IsAbstractType() {
+		return false
+	}
+
+	for _, p := range g.schema.GetPossibleTypes(def) {
+		if p.Name == t.Name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isPolymorphic reports whether a selection on the interface or union def
+// contains fragments narrowed to one of its possible types.
+func isPolymorphic(def *ast.Definition, sel ast.SelectionSet) bool {
+	if def == nil || !def.IsAbstractType() {
+		return false
+	}
+
 	for _, s := range sel {
 		switch s := s.(type) {
-		case *ast.Field:
-			if isPrimitiveGQLType(s.Definition.Type) {
-				printFieldDefinition(s.Definition, b, level)
-			} else {
-				fmt.Fprintf(b, "%s%s struct {\n", strings.Repeat("\t", level), toCammelCase(s.Alias))
-				if s.SelectionSet != nil {
-					generateResponseTypes(s.SelectionSet, b, level+1)
-				}
-				if s.Definition.Type.NonNull {
-					fmt.Fprintf(b, "%s} `json:\"%s\"`\n", strings.Repeat("\t", level), s.Alias)
-				} else {
-					fmt.Fprintf(b, "%s} `json:\"%s,omitempty\"`\n", strings.Repeat("\t", level), s.Alias)
-				}
-			}
 		case *ast.InlineFragment:
-			panic("inline fragment not supported")
+			if s.TypeCondition != "" && s.TypeCondition != def.Name {
+				return true
+			}
 		case *ast.FragmentSpread:
-			panic("fragment spread not supported")
+			if s.Definition != nil && s.Definition.TypeCondition != def.Name {
+				return true
+			}
 		}
 	}
+
+	return false
 }
 
-func printFieldDefinition(f *ast.FieldDefinition, b *bytes.Buffer, level int) {
+func (g *generator) printFieldDefinition(f *ast.FieldDefinition, b *bytes.Buffer, level int) {
 	if f.Type.NonNull {
-		// todo: add alias
-		fmt.Fprintf(b, "%s%s %s `json:\"%s\"`\n", strings.Repeat("\t", level), toCammelCase(f.Name), convertGraphQLTypeToGoType(f.Type), f.Name)
+		fmt.Fprintf(b, "%s%s %s `json:\"%s\"`\n", strings.Repeat("\t", level), toCammelCase(f.Name), g.goType(f.Type), f.Name)
 	} else {
-		fmt.Fprintf(b, "%s%s %s `json:\"%s,omitempty\"`\n", strings.Repeat("\t", level), toCammelCase(f.Name), convertGraphQLTypeToGoType(f.Type), f.Name)
+		fmt.Fprintf(b, "%s%s %s `json:\"%s,omitempty\"`\n", strings.Repeat("\t", level), toCammelCase(f.Name), g.goType(f.Type), f.Name)
 	}
 }
 
-func convertGraphQLTypeToGoType(typ *ast.Type) string {
-	if typ.Elem != nil {
-		return "[]" + convertGraphQLTypeToGoType(typ.Elem)
+// convertGraphQLTypeToGoType returns the Go type of the named type, the
+// list and nullable wrappers are added by goType
+func (g *generator) convertGraphQLTypeToGoType(typ *ast.Type) string {
+	if mapping, ok := g.options.Scalars[typ.Name()]; ok {
+		return g.scalarType(mapping)
 	}
+
 	switch typ.Name() {
 	case "String":
 		return "string"
@@ -201,15 +657,192 @@ func convertGraphQLTypeToGoType(typ *ast.Type) string {
 	}
 }
 
-func isPrimitiveGQLType(typ *ast.Type) bool {
-	switch typ.Name() {
-	case "String", "Int", "Float", "Boolean", "ID":
-		return true
-	default:
+// scalarType returns the Go type of a scalar mapping and adds its import
+func (g *generator) scalarType(mapping string) string {
+	pointer := ""
+	if strings.HasPrefix(mapping, "*") {
+		pointer, mapping = "*", mapping[1:]
+	}
+
+	// builtin types have no import path
+	idx := strings.LastIndex(mapping, ".")
+	if idx == -1 {
+		return pointer + mapping
+	}
+
+	importPath, typeName := mapping[:idx], mapping[idx+1:]
+
+	name := g.packageName(importPath)
+	if name != path.Base(importPath) {
+		g.imports[importPath] = name
+	} else {
+		g.imports[importPath] = ""
+	}
+
+	return pointer + name + "." + typeName
+}
+
+// packageName returns the name the package is used with in the generated
+// code. The name is numbered when another package is already used with it.
+func (g *generator) packageName(importPath string) string {
+	name := importName(importPath)
+
+	unique := name
+	for i := 2; g.packages[unique] != "" && g.packages[unique] != importPath; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	g.packages[unique] = importPath
+
+	return unique
+}
+
+// importName guesses the package name of an import path, dropping the major
+// version suffix, e.g. gopkg.in/yaml.v2 and github.com/foo/bar/v2 are yaml and bar
+func importName(importPath string) string {
+	name := path.Base(importPath)
+	if isMajorVersion(name) && path.Dir(importPath) != "." {
+		name = path.Base(path.Dir(importPath))
+	}
+
+	if idx := strings.Index(name, "."); idx > 0 {
+		name = name[:idx]
+	}
+
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.ReplaceAll(name, "-", "")
+
+	return name
+}
+
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
 		return false
 	}
+
+	for _, r := range s[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// inputField is a field of an input object or request struct
+type inputField struct {
+	name     string
+	key      string
+	nullable bool
+}
+
+// generateInputMarshaler generates the MarshalJSON method of an input object
+// or request struct for the NullableOptional strategy. omitempty does not
+// omit a struct, so the absent Optional fields are left out by the method
+// instead, GraphQL tells an absent argument and a null one apart.
+func (g *generator) generateInputMarshaler(b *bytes.Buffer, typeName string, fields []inputField) {
+	if g.options.Nullable != NullableOptional {
+		return
+	}
+
+	nullable := false
+	for _, f := range fields {
+		nullable = nullable || f.nullable
+	}
+	if !nullable {
+		return
+	}
+
+	g.imports["encoding/json"] = ""
+
+	fmt.Fprintf(b, "// MarshalJSON leaves the absent optional fields out.\n")
+	fmt.Fprintf(b, "func (v %s) MarshalJSON() ([]byte, error) {\n", typeName)
+	fmt.Fprintf(b, "\tfields := make(map[string]interface{}, %d)\n", len(fields))
+	for _, f := range fields {
+		if f.nullable {
+			fmt.Fprintf(b, "\tif v.%s.Set {\n\t\tfields[%q] = v.%s\n\t}\n", f.name, f.key, f.name)
+		} else {
+			fmt.Fprintf(b, "\tfields[%q] = v.%s\n", f.key, f.name)
+		}
+	}
+	fmt.Fprintf(b, "\treturn json.Marshal(fields)\n")
+	fmt.Fprintf(b, "}\n")
+}
+
+// generateOptionalType generates the Optional type used by the NullableOptional strategy
+func (g *generator) generateOptionalType(b *bytes.Buffer) {
+	g.imports["encoding/json"] = ""
+
+	r := strings.NewReplacer("{Optional}", g.optional.typ, "{Some}", g.optional.some, "{Null}", g.optional.null)
+	r.WriteString(b, `// {Optional} is a nullable value which tells null, absent and a value apart.
+// The zero value is absent. Set reports whether the value is present and Null
+// whether it is explicitly null, Value holds the value otherwise. The absent
+// fields of the input and request structs are left out of the request,
+// elsewhere an absent value is marshaled as null.
+type {Optional}[T any] struct {
+	Value T
+	Set   bool
+	Null  bool
+}
+
+// {Some} returns an {Optional} holding the value.
+func {Some}[T any](value T) {Optional}[T] {
+	return {Optional}[T]{Value: value, Set: true}
+}
+
+// {Null} returns an {Optional} which is explicitly null.
+func {Null}[T any]() {Optional}[T] {
+	return {Optional}[T]{Set: true, Null: true}
+}
+
+// Get returns the value and whether it is present and not null.
+func (o {Optional}[T]) Get() (T, bool) {
+	return o.Value, o.Set && !o.Null
+}
+
+// IsNull reports whether the value is explicitly null.
+func (o {Optional}[T]) IsNull() bool {
+	return o.Set && o.Null
 }
 
+// IsSet reports whether the value is present, either null or not.
+func (o {Optional}[T]) IsSet() bool {
+	return o.Set
+}
+
+func (o {Optional}[T]) MarshalJSON() ([]byte, error) {
+	if !o.Set || o.Null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+
+func (o *{Optional}[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*o = {Null}[T]()
+		return nil
+	}
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*o = {Some}(value)
+	return nil
+}
+`)
+}
+
+// fragmentTypeName returns the name of the struct generated for a fragment.
+func fragmentTypeName(name string) string {
+	return toCammelCase(name) + "Fragment"
+}
+
+// toCammelCase exports the name, leading underscores are dropped
+// so names like __typename are exported as well.
 func toCammelCase(s string) string {
+	s = strings.TrimLeft(s, "_")
+	if s == "" {
+		return s
+	}
+
 	return strings.ToUpper(s[:1]) + s[1:]
 }
@@ -32,6 +32,9 @@ func TestGen(t *testing.T) {
 		query    string
 		schema   string
 		expected string
+		nullable gen.NullableStrategy
+		scalars  map[string]string
+		naming   gen.NamingStrategy
 	}{
 		{
 			name:     "simple query",
@@ -39,6 +42,108 @@ func TestGen(t *testing.T) {
 			schema:   "./testdata/schema.graphql",
 			expected: "./testdata/types.txt",
 		},
+		{
+			name:     "fragment spreads",
+			query:    "./testdata/fragment_query.graphql",
+			schema:   "./testdata/schema.graphql",
+			expected: "./testdata/fragment_types.txt",
+		},
+		{
+			name:     "inline fragments on interfaces and unions",
+			query:    "./testdata/polymorphic_query.graphql",
+			schema:   "./testdata/polymorphic_schema.graphql",
+			expected: "./testdata/polymorphic_types.txt",
+		},
+		{
+			name:     "aliased siblings of polymorphic selections",
+			query:    "./testdata/polymorphic_alias_query.graphql",
+			schema:   "./testdata/polymorphic_schema.graphql",
+			expected: "./testdata/polymorphic_alias_types.txt",
+		},
+		{
+			name:     "list selections",
+			query:    "./testdata/list_query.graphql",
+			schema:   "./testdata/list_schema.graphql",
+			expected: "./testdata/list_types.txt",
+		},
+		{
+			name:     "nullable pointers",
+			query:    "./testdata/query.graphql",
+			schema:   "./testdata/schema.graphql",
+			expected: "./testdata/nullable_pointer_types.txt",
+			nullable: gen.NullablePointer,
+		},
+		{
+			name:     "nullable optional",
+			query:    "./testdata/list_query.graphql",
+			schema:   "./testdata/list_schema.graphql",
+			expected: "./testdata/nullable_optional_types.txt",
+			nullable: gen.NullableOptional,
+		},
+		{
+			name:     "nullable optional inputs",
+			query:    "./testdata/query.graphql",
+			schema:   "./testdata/schema.graphql",
+			expected: "./testdata/nullable_optional_input_types.txt",
+			nullable: gen.NullableOptional,
+		},
+		{
+			name:     "nullable optional with names taken by the schema",
+			query:    "./testdata/optional_names_query.graphql",
+			schema:   "./testdata/optional_names_schema.graphql",
+			expected: "./testdata/optional_names_types.txt",
+			nullable: gen.NullableOptional,
+		},
+		{
+			name:     "named types",
+			query:    "./testdata/named_query.graphql",
+			schema:   "./testdata/list_schema.graphql",
+			expected: "./testdata/named_types.txt",
+		},
+		{
+			name:     "named types by type",
+			query:    "./testdata/named_query.graphql",
+			schema:   "./testdata/list_schema.graphql",
+			expected: "./testdata/named_type_types.txt",
+			naming:   gen.NamingType,
+		},
+		{
+			name:     "merged fields",
+			query:    "./testdata/merge_query.graphql",
+			schema:   "./testdata/list_schema.graphql",
+			expected: "./testdata/merge_types.txt",
+		},
+		{
+			name:     "identical selections of different operations",
+			query:    "./testdata/roots_query.graphql",
+			schema:   "./testdata/list_schema.graphql",
+			expected: "./testdata/roots_types.txt",
+		},
+		{
+			name:     "custom scalars",
+			query:    "./testdata/scalar_query.graphql",
+			schema:   "./testdata/scalar_schema.graphql",
+			expected: "./testdata/scalar_types.txt",
+			scalars: map[string]string{
+				"DateTime": "time.Time",
+				"JSON":     "encoding/json.RawMessage",
+				"Decimal":  "github.com/shopspring/decimal.Decimal",
+				"ID":       "int64",
+			},
+		},
+		{
+			name:     "custom scalars with colliding package names",
+			query:    "./testdata/scalar_query.graphql",
+			schema:   "./testdata/scalar_schema.graphql",
+			expected: "./testdata/scalar_collision_types.txt",
+			nullable: gen.NullableOptional,
+			scalars: map[string]string{
+				"DateTime": "example.com/a/types.Time",
+				"Decimal":  "example.com/b/types.Decimal",
+				"JSON":     "example.com/c/json.Raw",
+				"ID":       "example.com/d/fmt.ID",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -74,9 +179,14 @@ func TestGen(t *testing.T) {
 				return
 			}
 
+			gen.InjectTypename(schema, query)
+
 			// generate types
 			types, err := gen.GenerateTypes(context.Background(), schema, query, gen.Options{
 				PackageName: "maps",
+				Nullable:    tt.nullable,
+				Scalars:     tt.scalars,
+				Naming:      tt.naming,
 			})
 			if err != nil {
 				t.Errorf("could not generate types: %v", err)
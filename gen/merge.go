@@ -0,0 +1,155 @@
+package gen
+
+import (
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// member is a member of a response struct, either a field or an embedded
+// fragment struct
+type member struct {
+	field    *ast.Field
+	fragment string
+}
+
+// mergeSelection returns the members of the struct of a selection set. The
+// fields with the same response key are merged into one field selecting the
+// fields of all of them, as GraphQL merges them in the response. The inline
+// fragments are flattened and each fragment is embedded once.
+//
+// A fragment selecting a field that is also selected outside of it is
+// flattened rather than embedded. The outer field would hide the field of the
+// embedded struct when decoding, leaving the fragment struct empty.
+//
+// When t is set only the fragments applying to the object type t are merged.
+func (g *generator) mergeSelection(sel ast.SelectionSet, t *ast.Definition) []member {
+	flattened := make(map[string]bool)
+
+	for {
+		members, overlapping := g.collectMembers(sel, t, flattened)
+		if overlapping == "" {
+			return members
+		}
+
+		// flattening a fragment adds outer fields, which may overlap the
+		// fragments embedded so far, so the members are collected again
+		flattened[overlapping] = true
+	}
+}
+
+// collectMembers returns the members of the struct of a selection set, the
+// flattened fragments are merged with the fields of the selection set. It also
+// returns the name of the first embedded fragment selecting a field selected
+// outside of it, if any.
+func (g *generator) collectMembers(sel ast.SelectionSet, t *ast.Definition, flattened map[string]bool) ([]member, string) {
+	var members []member
+	fields := make(map[string]*ast.Field)
+	seen := make(map[string]bool)
+	var embedded []*ast.FragmentDefinition
+
+	var collect func(sel ast.SelectionSet)
+	collect = func(sel ast.SelectionSet) {
+		for _, s := range sel {
+			switch s := s.(type) {
+			case *ast.Field:
+				if f, ok := fields[s.Alias]; ok {
+					mergeField(f, s)
+					continue
+				}
+				f := copyField(s)
+				fields[s.Alias] = f
+				members = append(members, member{field: f})
+			case *ast.InlineFragment:
+				if t == nil || g.fragmentApplies(s.TypeCondition, t) {
+					collect(s.SelectionSet)
+				}
+			case *ast.FragmentSpread:
+				if s.Definition == nil || t != nil && !g.fragmentApplies(s.Definition.TypeCondition, t) {
+					continue
+				}
+				if seen[s.Name] {
+					continue
+				}
+				seen[s.Name] = true
+
+				if flattened[s.Name] {
+					collect(s.Definition.SelectionSet)
+					continue
+				}
+				embedded = append(embedded, s.Definition)
+				members = append(members, member{fragment: s.Name})
+			}
+		}
+	}
+	collect(sel)
+
+	// the response keys selected outside of each fragment
+	selected := make(map[string]bool)
+	for key := range fields {
+		selected[key] = true
+	}
+	for _, f := range embedded {
+		keys := fragmentKeys(f)
+		if overlaps(keys, selected) {
+			return members, f.Name
+		}
+		for _, key := range keys {
+			selected[key] = true
+		}
+	}
+
+	return members, ""
+}
+
+// fragmentKeys returns the response keys selected by the fragment, including
+// the fields of its inline fragments and of the fragments it spreads
+func fragmentKeys(f *ast.FragmentDefinition) []string {
+	var keys []string
+	visited := make(map[string]bool)
+
+	var collect func(sel ast.SelectionSet)
+	collect = func(sel ast.SelectionSet) {
+		for _, s := range sel {
+			switch s := s.(type) {
+			case *ast.Field:
+				keys = append(keys, s.Alias)
+			case *ast.InlineFragment:
+				collect(s.SelectionSet)
+			case *ast.FragmentSpread:
+				if s.Definition == nil || visited[s.Name] {
+					continue
+				}
+				visited[s.Name] = true
+				collect(s.Definition.SelectionSet)
+			}
+		}
+	}
+	visited[f.Name] = true
+	collect(f.SelectionSet)
+
+	return keys
+}
+
+// overlaps reports whether one of the keys is selected
+func overlaps(keys []string, selected map[string]bool) bool {
+	for _, key := range keys {
+		if selected[key] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mergeField adds the selections of s to the merged field f
+func mergeField(f *ast.Field, s *ast.Field) {
+	f.SelectionSet = append(f.SelectionSet, s.SelectionSet...)
+}
+
+// copyField returns a copy of the field, so merging selections does not
+// change the query document
+func copyField(s *ast.Field) *ast.Field {
+	f := *s
+	f.SelectionSet = append(ast.SelectionSet(nil), s.SelectionSet...)
+
+	return &f
+}
@@ -0,0 +1,97 @@
+package gen
+
+import (
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// OperationFragments returns the fragments used by the operation, including
+// the fragments used by other fragments, in the order they appear in the document.
+func OperationFragments(doc *ast.QueryDocument, op *ast.OperationDefinition) ast.FragmentDefinitionList {
+	used := make(map[string]bool)
+	collectFragmentSpreads(doc, op.SelectionSet, used)
+
+	fragments := make(ast.FragmentDefinitionList, 0, len(used))
+	for _, f := range doc.Fragments {
+		if used[f.Name] {
+			fragments = append(fragments, f)
+		}
+	}
+
+	return fragments
+}
+
+func collectFragmentSpreads(doc *ast.QueryDocument, sel ast.SelectionSet, used map[string]bool) {
+	for _, s := range sel {
+		switch s := s.(type) {
+		case *ast.Field:
+			collectFragmentSpreads(doc, s.SelectionSet, used)
+		case *ast.InlineFragment:
+			collectFragmentSpreads(doc, s.SelectionSet, used)
+		case *ast.FragmentSpread:
+			if used[s.Name] {
+				continue
+			}
+			used[s.Name] = true
+
+			if f := doc.Fragments.ForName(s.Name); f != nil {
+				collectFragmentSpreads(doc, f.SelectionSet, used)
+			}
+		}
+	}
+}
+
+// InjectTypename adds a __typename field to every selection on an interface
+// or union that is narrowed by fragments, so the response can be decoded
+// into the type generated for each possible type. The document must be validated.
+func InjectTypename(schema *ast.Schema, doc *ast.QueryDocument) {
+	for _, op := range doc.Operations {
+		op.SelectionSet = injectTypename(schema, op.SelectionSet, nil)
+	}
+
+	for _, f := range doc.Fragments {
+		f.SelectionSet = injectTypename(schema, f.SelectionSet, f.Definition)
+	}
+}
+
+func injectTypename(schema *ast.Schema, sel ast.SelectionSet, def *ast.Definition) ast.SelectionSet {
+	for _, s := range sel {
+		switch s := s.(type) {
+		case *ast.Field:
+			if s.Definition != nil {
+				s.SelectionSet = injectTypename(schema, s.SelectionSet, schema.Types[s.Definition.Type.Name()])
+			}
+		case *ast.InlineFragment:
+			if s.TypeCondition != "" {
+				s.SelectionSet = injectTypename(schema, s.SelectionSet, schema.Types[s.TypeCondition])
+			} else {
+				s.SelectionSet = injectTypename(schema, s.SelectionSet, s.ObjectDefinition)
+			}
+		}
+	}
+
+	if !isPolymorphic(def, sel) || hasTypename(sel) {
+		return sel
+	}
+
+	typename := &ast.Field{
+		Alias: "__typename",
+		Name:  "__typename",
+		Definition: &ast.FieldDefinition{
+			Name: "__typename",
+			Type: ast.NonNullNamedType("String", nil),
+		},
+		ObjectDefinition: def,
+	}
+
+	return append(ast.SelectionSet{typename}, sel...)
+}
+
+func hasTypename(sel ast.SelectionSet) bool {
+	for _, s := range sel {
+		if f, ok := s.(*ast.Field); ok && f.Name == "__typename" && f.Alias == "__typename" {
+			return true
+		}
+	}
+
+	return false
+}
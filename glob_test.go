@@ -0,0 +1,32 @@
+package gqlclientgen
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"*.graphql", "country.graphql", true},
+		{"*.graphql", "countries/country.graphql", false},
+		{"**/*.graphql", "country.graphql", true},
+		{"**/*.graphql", "countries/europe/country.graphql", true},
+		{"**/*.graphql", "country.gql", false},
+		{"admin/**", "admin/users/list.graphql", true},
+		{"admin/**", "public/list.graphql", false},
+		{"**/internal/*.graphql", "a/b/internal/x.graphql", true},
+		{"**/internal/*.graphql", "a/b/internal/c/x.graphql", false},
+	}
+
+	for _, tt := range tests {
+		match, err := matchGlob(tt.pattern, tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if match != tt.match {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, match, tt.match)
+		}
+	}
+}
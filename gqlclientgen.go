@@ -0,0 +1,73 @@
+// Package gqlclientgen generates Go clients for GraphQL services from their
+// schema and the operations of the project. It is the library used by the
+// gqlclientgen command, to generate clients from build tools or go generate
+// wrappers:
+//
+//	config, err := gqlclientgen.LoadConfig("gqlclientgen.yml")
+//	if err != nil {
+//		return err
+//	}
+//
+//	result, err := gqlclientgen.Generate(ctx, config)
+//	if err != nil {
+//		return err
+//	}
+//
+//	return result.WriteFiles()
+package gqlclientgen
+
+import (
+	"context"
+	"fmt"
+)
+
+// Result contains the files generated for the services of a config
+type Result struct {
+	// Files are the files of all the services, in the order of the services
+	// and in the order they are written for a service
+	Files []GeneratedFile
+}
+
+// Generate resolves the schema and the operations of every service of the
+// config and returns the generated files, without writing them. The paths of
+// the config are relative to the working directory.
+func Generate(ctx context.Context, config Config) (Result, error) {
+	app, err := New(config)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var result Result
+
+	for i := range app.Services {
+		s := &app.Services[i]
+
+		if err := s.ResolveSchema(ctx); err != nil {
+			return Result{}, fmt.Errorf("service %s: %w", s.Name, err)
+		}
+
+		if err := s.ResolveOperations(); err != nil {
+			return Result{}, fmt.Errorf("service %s: %w", s.Name, err)
+		}
+
+		files, err := s.GenerateFiles(ctx)
+		if err != nil {
+			return Result{}, fmt.Errorf("service %s: %w", s.Name, err)
+		}
+
+		result.Files = append(result.Files, files...)
+	}
+
+	return result, nil
+}
+
+// WriteFiles writes the files, creating their folders if needed
+func (r Result) WriteFiles() error {
+	for _, file := range r.Files {
+		if err := file.Write(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
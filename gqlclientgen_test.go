@@ -0,0 +1,76 @@
+package gqlclientgen
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	client := filepath.Join(dir, "starwars")
+
+	configFile := filepath.Join(dir, ConfigFileName)
+	err := os.WriteFile(configFile, []byte(fmt.Sprintf(`
+version: 1
+services:
+  - name: Star Wars API
+    package: starwars
+    schema: %s
+    operations:
+      root: %s
+    client:
+      root: %s
+`, filepath.Join(sampleProject, "starwars.graphql"), filepath.Join(sampleProject, "gql", "starwars"), client)), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Generate(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the files are the ones of the sample project, generated from the same
+	// schema and operations
+	expected := []string{IntrospectionFileName, SchemaFileName, ModelFileName, ClientFileName}
+	if len(result.Files) != len(expected) {
+		t.Fatalf("expected %d files, got %d", len(expected), len(result.Files))
+	}
+
+	for i, file := range result.Files {
+		if file.Path != filepath.Join(client, expected[i]) {
+			t.Fatalf("expected file %s, got %s", filepath.Join(client, expected[i]), file.Path)
+		}
+
+		sample, err := os.ReadFile(filepath.Join(sampleProject, "pkg", "starwars", expected[i]))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(sample) != string(file.Content) {
+			t.Errorf("expected %s to be the file of the sample project", file.Path)
+		}
+	}
+
+	if _, err := os.Stat(client); !os.IsNotExist(err) {
+		t.Fatalf("expected no file written by Generate, got %v", err)
+	}
+
+	if err := result.WriteFiles(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range expected {
+		if _, err := os.Stat(filepath.Join(client, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,553 @@
+package introspect
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+// astSource is the source of the definitions built from an introspection
+// result, used in the positions of the schema errors
+var astSource = &ast.Source{Name: "introspection"}
+
+// ToAST builds the gqlparser schema of the introspection result, without
+// converting it to SDL. The built-in types and directives are the ones of
+// gqlparser, like in a schema loaded with gqlparser.LoadSchema.
+func ToAST(schema *Schema) (*ast.Schema, error) {
+	doc, err := parser.ParseSchema(validator.Prelude)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prelude: %w", err)
+	}
+
+	builtins := map[string]bool{}
+	for _, def := range doc.Definitions {
+		builtins[def.Name] = true
+	}
+	for _, d := range doc.Directives {
+		builtins["@"+d.Name] = true
+	}
+
+	for _, t := range schema.Types {
+		if t.Name == nil || builtins[*t.Name] {
+			continue
+		}
+
+		def, err := toDefinition(t)
+		if err != nil {
+			return nil, fmt.Errorf("type %s: %w", *t.Name, err)
+		}
+
+		doc.Definitions = append(doc.Definitions, def)
+	}
+
+	for _, d := range schema.Directives {
+		if builtins["@"+d.Name] {
+			continue
+		}
+
+		def, err := toDirectiveDefinition(d)
+		if err != nil {
+			return nil, fmt.Errorf("directive @%s: %w", d.Name, err)
+		}
+
+		doc.Directives = append(doc.Directives, def)
+	}
+
+	schemaDef := &ast.SchemaDefinition{Position: position()}
+	if schema.Description != nil {
+		schemaDef.Description = *schema.Description
+	}
+
+	roots := []struct {
+		operation ast.Operation
+		t         *Type
+	}{
+		{ast.Query, schema.QueryType},
+		{ast.Mutation, schema.MutationType},
+		{ast.Subscription, schema.SubscriptionType},
+	}
+	for _, root := range roots {
+		if root.t == nil || root.t.Name == nil {
+			continue
+		}
+
+		schemaDef.OperationTypes = append(schemaDef.OperationTypes, &ast.OperationTypeDefinition{
+			Operation: root.operation,
+			Type:      *root.t.Name,
+			Position:  position(),
+		})
+	}
+	doc.Schema = append(doc.Schema, schemaDef)
+
+	s, err := validator.ValidateSchemaDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func toDefinition(t Type) (*ast.Definition, error) {
+	if t.Kind == nil {
+		return nil, fmt.Errorf("missing kind")
+	}
+
+	def := &ast.Definition{
+		Kind:     ast.DefinitionKind(*t.Kind),
+		Name:     *t.Name,
+		Position: position(),
+	}
+
+	if t.Description != nil {
+		def.Description = *t.Description
+	}
+
+	if t.SpecifiedByURL != nil {
+		def.Directives = append(def.Directives, &ast.Directive{
+			Name:      "specifiedBy",
+			Arguments: ast.ArgumentList{stringArgument("url", *t.SpecifiedByURL)},
+			Position:  position(),
+		})
+	}
+
+	for _, i := range t.Interfaces {
+		def.Interfaces = append(def.Interfaces, *i.Name)
+	}
+
+	if *t.Kind == TypeKindUnion {
+		for _, p := range t.PossibleTypes {
+			def.Types = append(def.Types, *p.Name)
+		}
+	}
+
+	for _, f := range t.Fields {
+		typ, err := toASTType(&f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+
+		args, err := toArgumentDefinitions(f.Args)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+
+		def.Fields = append(def.Fields, &ast.FieldDefinition{
+			Description: stringValue(f.Description),
+			Name:        f.Name,
+			Arguments:   args,
+			Type:        typ,
+			Directives:  deprecatedDirectives(f.IsDeprecated, f.DeprecationReason),
+			Position:    position(),
+		})
+	}
+
+	for _, f := range t.InputFields {
+		typ, err := toASTType(&f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("input field %s: %w", f.Name, err)
+		}
+
+		defaultValue, err := parseValue(f.DefaultValue)
+		if err != nil {
+			return nil, fmt.Errorf("input field %s: %w", f.Name, err)
+		}
+
+		def.Fields = append(def.Fields, &ast.FieldDefinition{
+			Description:  stringValue(f.Description),
+			Name:         f.Name,
+			DefaultValue: defaultValue,
+			Type:         typ,
+			Directives:   deprecatedDirectives(f.IsDeprecated, f.DeprecationReason),
+			Position:     position(),
+		})
+	}
+
+	for _, v := range t.EnumValues {
+		def.EnumValues = append(def.EnumValues, &ast.EnumValueDefinition{
+			Description: stringValue(v.Description),
+			Name:        v.Name,
+			Directives:  deprecatedDirectives(v.IsDeprecated, v.DeprecationReason),
+			Position:    position(),
+		})
+	}
+
+	return def, nil
+}
+
+func toDirectiveDefinition(d Directive) (*ast.DirectiveDefinition, error) {
+	args, err := toArgumentDefinitions(d.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	def := &ast.DirectiveDefinition{
+		Description:  stringValue(d.Description),
+		Name:         d.Name,
+		Arguments:    args,
+		IsRepeatable: d.IsRepeatable,
+		Position:     position(),
+	}
+
+	for _, l := range d.Locations {
+		def.Locations = append(def.Locations, ast.DirectiveLocation(l))
+	}
+
+	return def, nil
+}
+
+func toArgumentDefinitions(args []InputValue) (ast.ArgumentDefinitionList, error) {
+	var defs ast.ArgumentDefinitionList
+
+	for _, a := range args {
+		typ, err := toASTType(&a.Type)
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %w", a.Name, err)
+		}
+
+		defaultValue, err := parseValue(a.DefaultValue)
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %w", a.Name, err)
+		}
+
+		defs = append(defs, &ast.ArgumentDefinition{
+			Description:  stringValue(a.Description),
+			Name:         a.Name,
+			DefaultValue: defaultValue,
+			Type:         typ,
+			Directives:   deprecatedDirectives(a.IsDeprecated, a.DeprecationReason),
+			Position:     position(),
+		})
+	}
+
+	return defs, nil
+}
+
+// toASTType converts the type reference, a cut reference is an error
+func toASTType(t *Type) (*ast.Type, error) {
+	if t == nil || t.Kind == nil {
+		return nil, fmt.Errorf("incomplete type reference")
+	}
+
+	switch *t.Kind {
+	case TypeKindNonNull:
+		typ, err := toASTType(t.OfType)
+		if err != nil {
+			return nil, err
+		}
+		typ.NonNull = true
+
+		return typ, nil
+	case TypeKindList:
+		elem, err := toASTType(t.OfType)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ast.Type{Elem: elem, Position: position()}, nil
+	default:
+		if t.Name == nil {
+			return nil, fmt.Errorf("incomplete type reference")
+		}
+
+		return &ast.Type{NamedType: *t.Name, Position: position()}, nil
+	}
+}
+
+// parseValue parses the default value of an introspection result, which is
+// a GraphQL value literal
+func parseValue(value *string) (*ast.Value, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	doc, err := parser.ParseQuery(&ast.Source{Name: astSource.Name, Input: "{f(v: " + *value + ")}"})
+	if err != nil {
+		return nil, fmt.Errorf("invalid default value %s: %w", *value, err)
+	}
+
+	if len(doc.Operations) != 1 || len(doc.Fragments) != 0 || len(doc.Operations[0].SelectionSet) != 1 {
+		return nil, fmt.Errorf("invalid default value %s", *value)
+	}
+
+	field, ok := doc.Operations[0].SelectionSet[0].(*ast.Field)
+	if !ok || len(field.Arguments) != 1 || len(field.SelectionSet) != 0 {
+		return nil, fmt.Errorf("invalid default value %s", *value)
+	}
+
+	return field.Arguments[0].Value, nil
+}
+
+func deprecatedDirectives(isDeprecated bool, deprecationReason *string) ast.DirectiveList {
+	if !isDeprecated {
+		return nil
+	}
+
+	d := &ast.Directive{Name: "deprecated", Position: position()}
+	if deprecationReason != nil {
+		d.Arguments = ast.ArgumentList{stringArgument("reason", *deprecationReason)}
+	}
+
+	return ast.DirectiveList{d}
+}
+
+func stringArgument(name, value string) *ast.Argument {
+	return &ast.Argument{
+		Name:     name,
+		Value:    &ast.Value{Kind: ast.StringValue, Raw: value, Position: position()},
+		Position: position(),
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+func position() *ast.Position {
+	return &ast.Position{Src: astSource}
+}
+
+// FromAST returns the introspection result of the gqlparser schema, with the
+// types and directives sorted by name.
+func FromAST(schema *ast.Schema) *Schema {
+	s := &Schema{
+		QueryType:        namedType(schema.Query),
+		MutationType:     namedType(schema.Mutation),
+		SubscriptionType: namedType(schema.Subscription),
+	}
+
+	if schema.Description != "" {
+		s.Description = &schema.Description
+	}
+
+	names := make([]string, 0, len(schema.Types))
+	for name := range schema.Types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		s.Types = append(s.Types, fromDefinition(schema, schema.Types[name]))
+	}
+
+	directives := make([]string, 0, len(schema.Directives))
+	for name := range schema.Directives {
+		directives = append(directives, name)
+	}
+	sort.Strings(directives)
+
+	for _, name := range directives {
+		d := schema.Directives[name]
+
+		directive := Directive{
+			Name:         d.Name,
+			Description:  optionalString(d.Description),
+			Args:         fromArgumentDefinitions(schema, d.Arguments),
+			IsRepeatable: d.IsRepeatable,
+			Locations:    []string{},
+		}
+		for _, l := range d.Locations {
+			directive.Locations = append(directive.Locations, string(l))
+		}
+
+		s.Directives = append(s.Directives, directive)
+	}
+
+	return s
+}
+
+func fromDefinition(schema *ast.Schema, def *ast.Definition) Type {
+	kind := TypeKind(def.Kind)
+
+	t := Type{
+		Kind:        &kind,
+		Name:        &def.Name,
+		Description: optionalString(def.Description),
+	}
+
+	if specifiedBy := def.Directives.ForName("specifiedBy"); specifiedBy != nil {
+		if url := specifiedBy.Arguments.ForName("url"); url != nil {
+			t.SpecifiedByURL = &url.Value.Raw
+		}
+	}
+
+	switch def.Kind {
+	case ast.Object, ast.Interface:
+		t.Fields = []Field{}
+		for _, f := range def.Fields {
+			// __schema and __type are added to the query type by gqlparser,
+			// they are not fields of the introspection result
+			if strings.HasPrefix(f.Name, "__") {
+				continue
+			}
+
+			isDeprecated, reason := deprecation(f.Directives)
+			t.Fields = append(t.Fields, Field{
+				Name:              f.Name,
+				Description:       optionalString(f.Description),
+				Args:              fromArgumentDefinitions(schema, f.Arguments),
+				Type:              fromASTType(schema, f.Type),
+				IsDeprecated:      isDeprecated,
+				DeprecationReason: reason,
+			})
+		}
+
+		t.Interfaces = []Type{}
+		for _, i := range def.Interfaces {
+			t.Interfaces = append(t.Interfaces, *namedType(schema.Types[i]))
+		}
+	case ast.InputObject:
+		t.InputFields = []InputField{}
+		for _, f := range def.Fields {
+			isDeprecated, reason := deprecation(f.Directives)
+			t.InputFields = append(t.InputFields, InputField{
+				Name:              f.Name,
+				Description:       optionalString(f.Description),
+				Type:              fromASTType(schema, f.Type),
+				DefaultValue:      valueString(f.DefaultValue),
+				IsDeprecated:      isDeprecated,
+				DeprecationReason: reason,
+			})
+		}
+	case ast.Enum:
+		t.EnumValues = []EnumValue{}
+		for _, v := range def.EnumValues {
+			isDeprecated, reason := deprecation(v.Directives)
+			t.EnumValues = append(t.EnumValues, EnumValue{
+				Name:              v.Name,
+				Description:       optionalString(v.Description),
+				IsDeprecated:      isDeprecated,
+				DeprecationReason: reason,
+			})
+		}
+	}
+
+	switch def.Kind {
+	case ast.Union:
+		t.PossibleTypes = []Type{}
+		for _, name := range def.Types {
+			t.PossibleTypes = append(t.PossibleTypes, *namedType(schema.Types[name]))
+		}
+	case ast.Interface:
+		// the possible types of an interface are its object implementations,
+		// sorted as the map of gqlparser has no order
+		var names []string
+		for _, p := range schema.GetPossibleTypes(def) {
+			if p.Kind == ast.Object {
+				names = append(names, p.Name)
+			}
+		}
+		sort.Strings(names)
+
+		t.PossibleTypes = []Type{}
+		for _, name := range names {
+			t.PossibleTypes = append(t.PossibleTypes, *namedType(schema.Types[name]))
+		}
+	}
+
+	return t
+}
+
+func fromArgumentDefinitions(schema *ast.Schema, args ast.ArgumentDefinitionList) []InputValue {
+	values := []InputValue{}
+
+	for _, a := range args {
+		isDeprecated, reason := deprecation(a.Directives)
+		values = append(values, InputValue{
+			Name:              a.Name,
+			Description:       optionalString(a.Description),
+			Type:              fromASTType(schema, a.Type),
+			DefaultValue:      valueString(a.DefaultValue),
+			IsDeprecated:      isDeprecated,
+			DeprecationReason: reason,
+		})
+	}
+
+	return values
+}
+
+func fromASTType(schema *ast.Schema, typ *ast.Type) Type {
+	if typ.NonNull {
+		nullable := *typ
+		nullable.NonNull = false
+
+		ofType := fromASTType(schema, &nullable)
+		kind := TypeKindNonNull
+
+		return Type{Kind: &kind, OfType: &ofType}
+	}
+
+	if typ.Elem != nil {
+		ofType := fromASTType(schema, typ.Elem)
+		kind := TypeKindList
+
+		return Type{Kind: &kind, OfType: &ofType}
+	}
+
+	if def := schema.Types[typ.NamedType]; def != nil {
+		return *namedType(def)
+	}
+
+	// an undefined type is not expected in a validated schema
+	name := typ.NamedType
+
+	return Type{Name: &name}
+}
+
+// namedType returns the reference to the definition, nil for no definition
+func namedType(def *ast.Definition) *Type {
+	if def == nil {
+		return nil
+	}
+
+	kind := TypeKind(def.Kind)
+	name := def.Name
+
+	return &Type{Kind: &kind, Name: &name}
+}
+
+// deprecation returns the deprecation of the @deprecated directive, the
+// reason defaults to the one of the directive definition
+func deprecation(directives ast.DirectiveList) (bool, *string) {
+	d := directives.ForName("deprecated")
+	if d == nil {
+		return false, nil
+	}
+
+	if reason := d.Arguments.ForName("reason"); reason != nil && reason.Value != nil {
+		return true, &reason.Value.Raw
+	}
+
+	if d.Definition != nil {
+		if reason := d.Definition.Arguments.ForName("reason"); reason != nil && reason.DefaultValue != nil {
+			return true, &reason.DefaultValue.Raw
+		}
+	}
+
+	return true, nil
+}
+
+func valueString(v *ast.Value) *string {
+	if v == nil {
+		return nil
+	}
+
+	s := v.String()
+
+	return &s
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
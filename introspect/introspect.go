@@ -3,8 +3,12 @@ package introspect
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"os"
+	"sort"
 	"strings"
 
 	client "github.com/stefanprifti/gqlclient"
@@ -14,12 +18,16 @@ import (
 func convertObject(t *Type) string {
 	var sb strings.Builder
 
-	// type Name {
+	// """Description"""
+	typeDescription(&sb, t)
+
+	// type Name implements Interface {
 	typeDeclaration(&sb, t)
+	implementsClause(&sb, t)
 	openingBrace(&sb)
 
 	for _, f := range t.Fields {
-		// # Description
+		// """Description"""
 		fieldDescription(&sb, f.Description)
 
 		// fieldName(argName: ArgType): FieldType
@@ -41,7 +49,7 @@ func convertObject(t *Type) string {
 func convertInputObject(t *Type) string {
 	var sb strings.Builder
 
-	// # Description
+	// """Description"""
 	typeDescription(&sb, t)
 
 	// input Name {
@@ -49,12 +57,15 @@ func convertInputObject(t *Type) string {
 	openingBrace(&sb)
 
 	for _, f := range t.InputFields {
-		// # Description
+		// """Description"""
 		fieldDescription(&sb, f.Description)
 
 		// fieldName: FieldType
 		inputField(&sb, f)
 
+		// @deprecated(reason: "Use something else.")
+		deprecatedDirective(&sb, f.IsDeprecated, f.DeprecationReason)
+
 		// \n
 		newLine(&sb)
 	}
@@ -68,16 +79,15 @@ func convertInputObject(t *Type) string {
 func convertEnum(t *Type) string {
 	var sb strings.Builder
 
+	typeDescription(&sb, t)
+
 	sb.WriteString(fmt.Sprintf("enum %s {\n", *t.Name))
 
 	for _, f := range t.EnumValues {
-		if f.Description != nil {
-			sb.WriteString(fmt.Sprintf("\t# %s\n", *f.Description))
-		}
-		if f.IsDeprecated {
-			sb.WriteString(fmt.Sprintf("\t# Deprecated: %s\n", *f.DeprecationReason))
-		}
-		sb.WriteString(fmt.Sprintf("\t%s\n", f.Name))
+		fieldDescription(&sb, f.Description)
+		sb.WriteString(fmt.Sprintf("\t%s", f.Name))
+		deprecatedDirective(&sb, f.IsDeprecated, f.DeprecationReason)
+		newLine(&sb)
 	}
 
 	sb.WriteString("}\n")
@@ -92,11 +102,13 @@ func convertScalar(t *Type) string {
 
 	var sb strings.Builder
 
-	if t.Description != nil {
-		sb.WriteString(fmt.Sprintf("# %s\n", *t.Description))
-	}
+	typeDescription(&sb, t)
 
-	sb.WriteString(fmt.Sprintf("scalar %s\n", *t.Name))
+	sb.WriteString(fmt.Sprintf("scalar %s", *t.Name))
+	if t.SpecifiedByURL != nil {
+		sb.WriteString(fmt.Sprintf(" @specifiedBy(url: %s)", quoteString(*t.SpecifiedByURL)))
+	}
+	newLine(&sb)
 
 	return sb.String()
 }
@@ -104,32 +116,39 @@ func convertScalar(t *Type) string {
 func convertInterface(t *Type) string {
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("interface %s {\n", *t.Name))
+	// """Description"""
+	typeDescription(&sb, t)
 
-	// TODO: add implemented interfaces
+	// interface Name implements Interface {
+	sb.WriteString(fmt.Sprintf("interface %s ", *t.Name))
+	implementsClause(&sb, t)
+	openingBrace(&sb)
 
 	for _, f := range t.Fields {
-		if f.Description != nil {
-			sb.WriteString(fmt.Sprintf("# %s\n", *f.Description))
-		}
+		// """Description"""
+		fieldDescription(&sb, f.Description)
 
-		if f.IsDeprecated {
-			sb.WriteString(fmt.Sprintf("# Deprecated: %s\n", *f.DeprecationReason))
-		}
+		// fieldName(argName: ArgType): FieldType
+		fieldNameType(&sb, f)
 
-		sb.WriteString(fmt.Sprintf("\t%s: %s\n", f.Name, f.Type.String()))
+		// @deprecated(reason: "Use something else.")
+		deprecatedDirective(&sb, f.IsDeprecated, f.DeprecationReason)
 
+		// \n
+		newLine(&sb)
 	}
 
-	sb.WriteString("}\n")
+	// }
+	closingBrace(&sb)
 
 	return sb.String()
-
 }
 
 func convertUnion(t *Type) string {
 	var sb strings.Builder
 
+	typeDescription(&sb, t)
+
 	sb.WriteString(fmt.Sprintf("union %s = ", *t.Name))
 
 	for i, m := range t.PossibleTypes {
@@ -144,6 +163,87 @@ func convertUnion(t *Type) string {
 	return sb.String()
 }
 
+// convertDirective prints the directive definition.
+func convertDirective(d Directive) string {
+	var sb strings.Builder
+
+	description(&sb, "", d.Description)
+
+	// directive @name(argName: ArgType) on LOCATION | LOCATION
+	sb.WriteString(fmt.Sprintf("directive @%s%s", d.Name, arguments(d.Args, "")))
+	if d.IsRepeatable {
+		sb.WriteString(" repeatable")
+	}
+	sb.WriteString(fmt.Sprintf(" on %s\n", strings.Join(d.Locations, " | ")))
+
+	return sb.String()
+}
+
+// convertSchemaDefinition prints the schema definition, which is only needed
+// when a root type doesn't have the default name or the schema has a
+// description.
+func convertSchemaDefinition(schema *Schema) string {
+	roots := []struct {
+		operation   string
+		defaultName string
+		t           *Type
+	}{
+		{"query", "Query", schema.QueryType},
+		{"mutation", "Mutation", schema.MutationType},
+		{"subscription", "Subscription", schema.SubscriptionType},
+	}
+
+	custom := false
+	for _, root := range roots {
+		if root.t != nil && root.t.Name != nil && *root.t.Name != root.defaultName {
+			custom = true
+		}
+	}
+
+	hasDescription := schema.Description != nil && *schema.Description != ""
+	if !custom && !hasDescription {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	description(&sb, "", schema.Description)
+	sb.WriteString("schema {\n")
+	for _, root := range roots {
+		if root.t != nil && root.t.Name != nil {
+			sb.WriteString(fmt.Sprintf("\t%s: %s\n", root.operation, *root.t.Name))
+		}
+	}
+	sb.WriteString("}\n")
+
+	return sb.String()
+}
+
+// isBuiltinDirective returns true for the directives defined by the spec,
+// which are not printed.
+func isBuiltinDirective(name string) bool {
+	switch name {
+	case "include", "skip", "deprecated", "specifiedBy":
+		return true
+	default:
+		return false
+	}
+}
+
+// implementsClause prints the interfaces implemented by the type.
+func implementsClause(sb *strings.Builder, t *Type) {
+	if len(t.Interfaces) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(t.Interfaces))
+	for _, i := range t.Interfaces {
+		names = append(names, *i.Name)
+	}
+
+	sb.WriteString(fmt.Sprintf("implements %s ", strings.Join(names, " & ")))
+}
+
 func deprecatedDirective(sb *strings.Builder, isDeprecated bool, deprecationReason *string) {
 	if !isDeprecated {
 		return
@@ -151,8 +251,8 @@ func deprecatedDirective(sb *strings.Builder, isDeprecated bool, deprecationReas
 
 	sb.WriteString(" @deprecated")
 
-	if deprecationReason != nil && *deprecationReason != "" {
-		sb.WriteString(fmt.Sprintf("(reason: \"%s\")", *deprecationReason))
+	if deprecationReason != nil {
+		sb.WriteString(fmt.Sprintf("(reason: %s)", quoteString(*deprecationReason)))
 	}
 }
 
@@ -173,32 +273,51 @@ func typeDeclaration(sb *strings.Builder, t *Type) {
 }
 
 func fieldNameType(sb *strings.Builder, f Field) {
-	var args strings.Builder
+	sb.WriteString(fmt.Sprintf("\t%s%s: %s", f.Name, arguments(f.Args, "\t"), f.Type.String()))
+}
 
-	if len(f.Args) > 0 {
-		args.WriteString("(")
-		for idx, a := range f.Args {
-			if idx > 0 {
-				args.WriteString(", ")
-			}
-			args.WriteString(fmt.Sprintf("%s: %s", a.Name, a.Type.String()))
-			if a.DefaultValue != nil {
-				args.WriteString(fmt.Sprintf(" = %s", *a.DefaultValue))
-			}
+// arguments returns the argument list of a field or a directive. The list
+// is printed on multiple lines when an argument has a description.
+func arguments(args []InputValue, indent string) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	multiline := false
+	for _, a := range args {
+		if a.Description != nil && *a.Description != "" {
+			multiline = true
 		}
-		args.WriteString(")")
 	}
 
-	sb.WriteString(fmt.Sprintf("\t%s%s: %s", f.Name, args.String(), f.Type.String()))
-}
+	var sb strings.Builder
 
-func fieldDescription(sb *strings.Builder, description *string) {
-	if description != nil && *description != "" {
-		for _, line := range strings.Split(*description, "\n") {
-			sb.WriteString(fmt.Sprintf("\t# %s", line))
-			newLine(sb)
+	sb.WriteString("(")
+	for idx, a := range args {
+		if multiline {
+			newLine(&sb)
+			description(&sb, indent+"\t", a.Description)
+			sb.WriteString(indent + "\t")
+		} else if idx > 0 {
+			sb.WriteString(", ")
 		}
+		sb.WriteString(fmt.Sprintf("%s: %s", a.Name, a.Type.String()))
+		if a.DefaultValue != nil {
+			sb.WriteString(fmt.Sprintf(" = %s", *a.DefaultValue))
+		}
+		deprecatedDirective(&sb, a.IsDeprecated, a.DeprecationReason)
+	}
+	if multiline {
+		newLine(&sb)
+		sb.WriteString(indent)
 	}
+	sb.WriteString(")")
+
+	return sb.String()
+}
+
+func fieldDescription(sb *strings.Builder, d *string) {
+	description(sb, "\t", d)
 }
 
 func inputDeclaration(sb *strings.Builder, t *Type) {
@@ -206,12 +325,91 @@ func inputDeclaration(sb *strings.Builder, t *Type) {
 }
 
 func typeDescription(sb *strings.Builder, t *Type) {
-	if t.Description != nil && *t.Description != "" {
-		for _, line := range strings.Split(*t.Description, "\n") {
-			sb.WriteString(fmt.Sprintf("# %s", line))
-			newLine(sb)
+	description(sb, "", t.Description)
+}
+
+// description prints the description as a block string, or as a string when
+// a block string would not keep its whitespace.
+func description(sb *strings.Builder, indent string, d *string) {
+	if d == nil || *d == "" {
+		return
+	}
+
+	if !blockStringSafe(*d) {
+		sb.WriteString(indent + quoteString(*d))
+		newLine(sb)
+		return
+	}
+
+	sb.WriteString(indent + `"""`)
+	newLine(sb)
+	for _, line := range strings.Split(*d, "\n") {
+		if line != "" {
+			sb.WriteString(indent + strings.ReplaceAll(line, `"""`, `\"""`))
 		}
+		newLine(sb)
 	}
+	sb.WriteString(indent + `"""`)
+	newLine(sb)
+}
+
+// blockStringSafe returns true if the value is unchanged by the common
+// indentation and blank lines removal of block strings.
+func blockStringSafe(value string) bool {
+	for _, r := range value {
+		// control characters can only be escaped in strings
+		if r < 0x20 && r != '\t' && r != '\n' {
+			return false
+		}
+	}
+
+	lines := strings.Split(value, "\n")
+	if strings.TrimLeft(lines[0], " \t") == "" || strings.TrimLeft(lines[len(lines)-1], " \t") == "" {
+		return false
+	}
+
+	for _, line := range lines {
+		if line != "" && line[0] != ' ' && line[0] != '\t' {
+			return true
+		}
+	}
+
+	// every line is indented, the common indentation would be removed
+	return false
+}
+
+// quoteString returns the value as a GraphQL string.
+func quoteString(value string) string {
+	var sb strings.Builder
+
+	sb.WriteString(`"`)
+	for _, r := range value {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\f':
+			sb.WriteString(`\f`)
+		default:
+			if r < 0x20 {
+				sb.WriteString(fmt.Sprintf(`\u%04x`, r))
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteString(`"`)
+
+	return sb.String()
 }
 
 func inputField(sb *strings.Builder, f InputField) {
@@ -249,6 +447,10 @@ func writeSchema(schema *Schema, w io.Writer) (err error) {
 		}
 	}()
 
+	if _, err := w.Write([]byte(convertSchemaDefinition(schema))); err != nil {
+		return fmt.Errorf("failed to write schema definition: %w", err)
+	}
+
 	for _, t := range schema.Types {
 		if t.Name != nil && strings.HasPrefix(*t.Name, "_") {
 			continue
@@ -295,29 +497,259 @@ func writeSchema(schema *Schema, w io.Writer) (err error) {
 		}
 	}
 
+	for _, d := range schema.Directives {
+		if isBuiltinDirective(d.Name) {
+			continue
+		}
+
+		_, err := w.Write([]byte(convertDirective(d)))
+		if err != nil {
+			return fmt.Errorf("failed to write directive: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// kindOrder is the order of the type kinds in the sorted SDL
+var kindOrder = map[TypeKind]int{
+	TypeKindObject:      0,
+	TypeKindInterface:   1,
+	TypeKindUnion:       2,
+	TypeKindEnum:        3,
+	TypeKindInputObject: 4,
+	TypeKindScalar:      5,
+}
+
+// sortSchema returns a copy of the schema in the order of the options, the
+// schema is returned as is when nothing is sorted.
+func sortSchema(schema *Schema, options TextOptions) *Schema {
+	if !options.Sort && !options.SortFields {
+		return schema
+	}
+
+	sorted := *schema
+	sorted.Types = append([]Type(nil), schema.Types...)
+	sorted.Directives = append([]Directive(nil), schema.Directives...)
+
+	if options.SortFields {
+		for i := range sorted.Types {
+			sortFields(&sorted.Types[i])
+		}
+	}
+
+	if !options.Sort {
+		return &sorted
+	}
+
+	// the root types are written first, in the order of the operations
+	roots := map[string]int{}
+	for i, root := range []*Type{schema.QueryType, schema.MutationType, schema.SubscriptionType} {
+		if root != nil && root.Name != nil {
+			roots[*root.Name] = i
+		}
+	}
+
+	sort.SliceStable(sorted.Types, func(i, j int) bool {
+		a, b := sorted.Types[i], sorted.Types[j]
+
+		rootA, isRootA := roots[*a.Name]
+		rootB, isRootB := roots[*b.Name]
+		if isRootA || isRootB {
+			if isRootA && isRootB {
+				return rootA < rootB
+			}
+			return isRootA
+		}
+
+		if kindOrder[*a.Kind] != kindOrder[*b.Kind] {
+			return kindOrder[*a.Kind] < kindOrder[*b.Kind]
+		}
+
+		return *a.Name < *b.Name
+	})
+
+	sort.SliceStable(sorted.Directives, func(i, j int) bool {
+		return sorted.Directives[i].Name < sorted.Directives[j].Name
+	})
+
+	return &sorted
+}
+
+// sortFields sorts copies of the fields, input fields and enum values of the
+// type by name.
+func sortFields(t *Type) {
+	t.Fields = append([]Field(nil), t.Fields...)
+	sort.SliceStable(t.Fields, func(i, j int) bool {
+		return t.Fields[i].Name < t.Fields[j].Name
+	})
+
+	t.InputFields = append([]InputField(nil), t.InputFields...)
+	sort.SliceStable(t.InputFields, func(i, j int) bool {
+		return t.InputFields[i].Name < t.InputFields[j].Name
+	})
+
+	t.EnumValues = append([]EnumValue(nil), t.EnumValues...)
+	sort.SliceStable(t.EnumValues, func(i, j int) bool {
+		return t.EnumValues[i].Name < t.EnumValues[j].Name
+	})
+}
+
+// Options configures the introspection request.
+type Options struct {
+	// Headers are added to the introspection request, e.g. Authorization
+	Headers http.Header
+	// HTTPClient is used to send the request, defaults to http.DefaultClient
+	HTTPClient *http.Client
+	// TypeRefDepth is the number of levels of the type references, e.g. 3
+	// for [String!]!, defaults to DefaultTypeRefDepth
+	TypeRefDepth int
+	// Features are the optional fields requested, when nil the features
+	// supported by the server are detected with a first query
+	Features *Features
+}
+
 // URL returns the schema from the given URL.
 func URL(url string) (*Schema, error) {
+	return Fetch(context.Background(), url, Options{})
+}
+
+// Fetch returns the schema from the given URL, sending the request with the options.
+func Fetch(ctx context.Context, url string, options Options) (*Schema, error) {
 	var schema struct {
 		Schema `json:"__schema"`
 	}
 
+	httpClient := options.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	if len(options.Headers) > 0 {
+		transport := httpClient.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+
+		withHeaders := *httpClient
+		withHeaders.Transport = &headerTransport{headers: options.Headers, base: transport}
+		httpClient = &withHeaders
+	}
+
 	gqlClient := client.New(client.Options{
-		Endpoint: url,
+		Endpoint:   url,
+		HTTPClient: httpClient,
 	})
-	err := gqlClient.Query(context.Background(), introspectionQuery, nil, &schema)
+
+	features := options.Features
+	if features == nil {
+		var result featuresResult
+		err := gqlClient.Query(ctx, featuresQuery, nil, &result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect introspection features: %w", err)
+		}
+
+		detected := result.features()
+		features = &detected
+	}
+
+	typeRefDepth := options.TypeRefDepth
+	if typeRefDepth <= 0 {
+		typeRefDepth = DefaultTypeRefDepth
+	}
+
+	err := gqlClient.Query(ctx, introspectionQuery(*features, typeRefDepth), nil, &schema)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := checkTypeRefs(&schema.Schema, typeRefDepth); err != nil {
+		return nil, err
+	}
+
 	return &schema.Schema, nil
 }
 
+// headerTransport adds the headers to every request
+type headerTransport struct {
+	headers http.Header
+	base    http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for key, values := range t.headers {
+		req.Header[key] = values
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+// JSON returns the schema from an introspection result. The result is either
+// the schema itself, like the schema.introspect.json written by gqlclientgen,
+// or a full introspection response containing __schema.
+func JSON(data []byte) (*Schema, error) {
+	var result struct {
+		Data *struct {
+			Schema *Schema `json:"__schema"`
+		} `json:"data"`
+		Schema *Schema `json:"__schema"`
+	}
+
+	err := json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode introspection result: %w", err)
+	}
+
+	switch {
+	case result.Data != nil && result.Data.Schema != nil:
+		return result.Data.Schema, nil
+	case result.Schema != nil:
+		return result.Schema, nil
+	}
+
+	var schema Schema
+	err = json.Unmarshal(data, &schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode introspection result: %w", err)
+	}
+
+	if schema.QueryType == nil && len(schema.Types) == 0 {
+		return nil, fmt.Errorf("introspection result contains no schema")
+	}
+
+	return &schema, nil
+}
+
+// File returns the schema from the introspection result stored in the file.
+func File(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return JSON(data)
+}
+
 func SchemaToText(schema *Schema) ([]byte, error) {
+	return SchemaToTextWithOptions(schema, TextOptions{})
+}
+
+// TextOptions configures the SDL written by SchemaToTextWithOptions.
+type TextOptions struct {
+	// Sort writes the root types first, then the other types by kind and
+	// name, and the directives by name. The types are otherwise written in
+	// the order of the introspection result.
+	Sort bool
+	// SortFields writes the fields, input fields and enum values by name.
+	SortFields bool
+}
+
+// SchemaToTextWithOptions converts the schema to SDL. Sorting makes the
+// output independent of the order in which the server returns the types.
+func SchemaToTextWithOptions(schema *Schema, options TextOptions) ([]byte, error) {
 	var buf bytes.Buffer
-	err := writeSchema(schema, &buf)
+	err := writeSchema(sortSchema(schema, options), &buf)
 	if err != nil {
 		return []byte(nil), err
 	}
@@ -1,39 +1,99 @@
 package introspect_test
 
 import (
-	"io/ioutil"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stefanprifti/gqlclientgen/introspect"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
+var (
+	update = flag.Bool("update", false, "update the SDL files of TestIntrospect from the recorded introspection results")
+	record = flag.Bool("record", false, "introspect the services of TestIntrospect and update the recorded results, it needs network access")
+)
+
+// TestIntrospect introspects a server replaying the recorded introspection
+// result of a service and compares the SDL with the expected one.
+//
+// The countries result is a real recording of the service. The brotforce and
+// swapi results are synthetic, they were rebuilt from earlier SDL files with
+// the implements clauses inferred, so they only cover the SDL writer and not
+// what the services return. Running with -record replaces them with real
+// recordings, the synthetic files can then be removed.
 func TestIntrospect(t *testing.T) {
 	cases := []struct {
-		name     string
-		url      string
-		fileName string
+		name      string
+		url       string
+		path      string
+		fileName  string
+		synthetic bool
 	}{
 		{
-			name:     "brotforce",
-			url:      "https://brotforce-bff-staging.mcmakler.com/",
-			fileName: "./testdata/brotforce-bff-staging.mcmakler.com.graphql",
+			name:      "brotforce",
+			url:       "https://brotforce-bff-staging.mcmakler.com/",
+			path:      "./testdata/brotforce-bff-staging.mcmakler.com.json",
+			fileName:  "./testdata/brotforce-bff-staging.mcmakler.com.graphql",
+			synthetic: true,
 		},
 		{
 			name:     "countries",
 			url:      "https://countries.trevorblades.com/graphql",
+			path:     "./testdata/countries.trevorblades.com.json",
 			fileName: "./testdata/countries.trevorblades.com.graphql",
 		},
 		{
-			name:     "swapi",
-			url:      "https://swapi-graphql.netlify.app/.netlify/functions/index",
-			fileName: "./testdata/swapi-graphql.netlify.app.graphql",
+			name:      "swapi",
+			url:       "https://swapi-graphql.netlify.app/.netlify/functions/index",
+			path:      "./testdata/swapi-graphql.netlify.app.json",
+			fileName:  "./testdata/swapi-graphql.netlify.app.graphql",
+			synthetic: true,
 		},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			schema, err := introspect.URL(tc.url)
+			path := tc.path
+			if *record {
+				schema, err := introspect.URL(tc.url)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				data, err := json.Marshal(schema)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if err := os.WriteFile(path, data, 0644); err != nil {
+					t.Fatal(err)
+				}
+			} else if tc.synthetic {
+				// a real recording is used once there is one
+				if _, err := os.Stat(path); os.IsNotExist(err) {
+					path = strings.TrimSuffix(path, ".json") + ".synthetic.json"
+				}
+			}
+
+			recorded, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"data":{"__schema":%s}}`, recorded)
+			}))
+			defer server.Close()
+
+			schema, err := introspect.URL(server.URL)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -43,13 +103,55 @@ func TestIntrospect(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			f, err := os.OpenFile(tc.fileName, os.O_RDWR|os.O_CREATE, 0755)
+			if *update || *record {
+				if err := os.WriteFile(tc.fileName, txt, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			expectedTxt, err := os.ReadFile(tc.fileName)
 			if err != nil {
 				t.Fatal(err)
 			}
-			defer f.Close()
 
-			expectedTxt, err := ioutil.ReadAll(f)
+			if string(txt) != string(expectedTxt) {
+				t.Fatalf("expected %s, got %s", expectedTxt, txt)
+			}
+
+			// the SDL is a valid schema
+			if _, err := gqlparser.LoadSchema(&ast.Source{Name: tc.fileName, Input: string(txt)}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestFile(t *testing.T) {
+	cases := []struct {
+		name     string
+		path     string
+		fileName string
+	}{
+		{
+			name:     "countries",
+			path:     "./testdata/countries.trevorblades.com.json",
+			fileName: "./testdata/countries.trevorblades.com.graphql",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			schema, err := introspect.File(tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			txt, err := introspect.SchemaToText(schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectedTxt, err := os.ReadFile(tc.fileName)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -57,11 +159,607 @@ func TestIntrospect(t *testing.T) {
 			if string(txt) != string(expectedTxt) {
 				t.Fatalf("expected %s, got %s", expectedTxt, txt)
 			}
+		})
+	}
+}
+
+func TestSchemaToText(t *testing.T) {
+	data := `{
+		"queryType": {"name": "Root"},
+		"types": [
+			{"kind": "OBJECT", "name": "Root", "fields": [
+				{"name": "node", "args": [{"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}], "type": {"kind": "INTERFACE", "name": "Node"}},
+				{"name": "films", "args": [{"name": "order", "type": {"kind": "INPUT_OBJECT", "name": "FilmOrder"}}], "type": {"kind": "LIST", "ofType": {"kind": "OBJECT", "name": "Film"}}}
+			]},
+			{"kind": "INTERFACE", "name": "Node", "fields": [
+				{"name": "id", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}
+			], "possibleTypes": [{"kind": "OBJECT", "name": "Film"}]},
+			{"kind": "OBJECT", "name": "Film", "interfaces": [{"kind": "INTERFACE", "name": "Node"}], "fields": [
+				{"name": "id", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}},
+				{"name": "title", "args": [], "type": {"kind": "SCALAR", "name": "String"}, "isDeprecated": true, "deprecationReason": "Use name"}
+			]},
+			{"kind": "INPUT_OBJECT", "name": "FilmOrder", "inputFields": [
+				{"name": "field", "type": {"kind": "ENUM", "name": "FilmField"}},
+				{"name": "desc", "type": {"kind": "SCALAR", "name": "Boolean"}, "isDeprecated": true, "deprecationReason": "Use direction"}
+			]},
+			{"kind": "ENUM", "name": "FilmField", "enumValues": [
+				{"name": "TITLE", "isDeprecated": false},
+				{"name": "EPISODE", "isDeprecated": true, "deprecationReason": "Use TITLE"}
+			]},
+			{"kind": "SCALAR", "name": "ID"},
+			{"kind": "SCALAR", "name": "String"},
+			{"kind": "SCALAR", "name": "Boolean"}
+		],
+		"directives": [
+			{"name": "deprecated", "locations": ["FIELD_DEFINITION"], "args": []},
+			{"name": "cacheControl", "locations": ["FIELD_DEFINITION", "OBJECT"], "args": [
+				{"name": "maxAge", "type": {"kind": "SCALAR", "name": "Int"}, "defaultValue": "60"}
+			]}
+		]
+	}`
+
+	schema, err := introspect.JSON([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sdl, err := introspect.SchemaToText(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txt := string(sdl)
+
+	for _, expected := range []string{
+		"schema {\n\tquery: Root\n}\n",
+		"type Film implements Node {",
+		"\ttitle: String @deprecated(reason: \"Use name\")\n",
+		"\tdesc: Boolean @deprecated(reason: \"Use direction\")\n",
+		"\tEPISODE @deprecated(reason: \"Use TITLE\")\n",
+		"directive @cacheControl(maxAge: Int = 60) on FIELD_DEFINITION | OBJECT\n",
+	} {
+		if !strings.Contains(txt, expected) {
+			t.Errorf("expected %q in\n%s", expected, txt)
+		}
+	}
+
+	if strings.Contains(txt, "directive @deprecated") {
+		t.Errorf("expected built-in directives to be skipped in\n%s", txt)
+	}
+
+	parsed, err := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: txt})
+	if err != nil {
+		t.Fatalf("failed to load schema: %v\n%s", err, txt)
+	}
+
+	if parsed.Query == nil || parsed.Query.Name != "Root" {
+		t.Errorf("expected query type Root, got %v", parsed.Query)
+	}
+
+	if interfaces := parsed.Types["Film"].Interfaces; len(interfaces) != 1 || interfaces[0] != "Node" {
+		t.Errorf("expected Film to implement Node, got %v", interfaces)
+	}
+
+	if parsed.Directives["cacheControl"] == nil {
+		t.Error("expected directive cacheControl")
+	}
+}
+
+func TestSchemaToTextSorted(t *testing.T) {
+	schema, err := introspect.File("./testdata/countries.trevorblades.com.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the same schema with the types and fields in the reverse order
+	reversed := *schema
+	reversed.Types = nil
+	for i := len(schema.Types) - 1; i >= 0; i-- {
+		typ := schema.Types[i]
+		typ.Fields = nil
+		for j := len(schema.Types[i].Fields) - 1; j >= 0; j-- {
+			typ.Fields = append(typ.Fields, schema.Types[i].Fields[j])
+		}
+		reversed.Types = append(reversed.Types, typ)
+	}
+
+	options := introspect.TextOptions{Sort: true, SortFields: true}
+
+	expected, err := introspect.SchemaToTextWithOptions(schema, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := introspect.SchemaToTextWithOptions(&reversed, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(expected) != string(got) {
+		t.Fatalf("expected the same SDL for reordered types, got\n%s\nand\n%s", expected, got)
+	}
+
+	var declarations []string
+	for _, line := range strings.Split(string(got), "\n") {
+		if strings.HasPrefix(line, "type ") || strings.HasPrefix(line, "input ") {
+			declarations = append(declarations, strings.TrimSuffix(strings.TrimSuffix(line, "{"), " "))
+		}
+	}
+
+	expectedDeclarations := []string{
+		"type Query",
+		"type Continent",
+		"type Country",
+		"type Language",
+		"type State",
+		"input ContinentFilterInput",
+		"input CountryFilterInput",
+		"input LanguageFilterInput",
+		"input StringQueryOperatorInput",
+	}
+	if strings.Join(declarations, "\n") != strings.Join(expectedDeclarations, "\n") {
+		t.Errorf("expected declarations\n%s\ngot\n%s", strings.Join(expectedDeclarations, "\n"), strings.Join(declarations, "\n"))
+	}
+
+	// the fields are preserved without SortFields
+	unsorted, err := introspect.SchemaToTextWithOptions(&reversed, introspect.TextOptions{Sort: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(unsorted), "type Query {\n\tlanguages(") {
+		t.Errorf("expected the fields of Query in the reverse order, got\n%s", unsorted)
+	}
+}
+
+func TestSchemaToTextRoundTrip(t *testing.T) {
+	data := `{
+		"queryType": {"name": "Query"},
+		"types": [
+			{"kind": "OBJECT", "name": "Query", "description": "The \"root\" query.\nSee \"\"\"docs\"\"\" and C:\\path.", "fields": [
+				{"name": "search", "description": "  indented first line\n  and second line", "args": [
+					{"name": "text", "description": "Text to search,\n\n\twith a tab.", "type": {"kind": "SCALAR", "name": "String"}, "defaultValue": "\"say \\\"hi\\\"\""},
+					{"name": "first", "description": "", "type": {"kind": "SCALAR", "name": "Int"}, "defaultValue": "10"}
+				], "type": {"kind": "LIST", "ofType": {"kind": "ENUM", "name": "Color"}}, "isDeprecated": true, "deprecationReason": "Use \"find\" instead.\nIt is faster."},
+				{"name": "find", "description": "ends with a quote \"", "args": [
+					{"name": "filter", "type": {"kind": "INPUT_OBJECT", "name": "Filter"}, "defaultValue": "{color:RED,tags:[\"a\\nb\"]}"}
+				], "type": {"kind": "SCALAR", "name": "String"}}
+			]},
+			{"kind": "INPUT_OBJECT", "name": "Filter", "description": "\n\nleading blank lines", "inputFields": [
+				{"name": "color", "description": "windows\r\nline", "type": {"kind": "ENUM", "name": "Color"}, "defaultValue": "BLUE"},
+				{"name": "tags", "type": {"kind": "LIST", "ofType": {"kind": "SCALAR", "name": "String"}}, "isDeprecated": true, "deprecationReason": "back\\slash"}
+			]},
+			{"kind": "ENUM", "name": "Color", "description": "trailing blank line\n", "enumValues": [
+				{"name": "RED", "description": "\u0001 control character", "isDeprecated": false},
+				{"name": "BLUE", "isDeprecated": true, "deprecationReason": ""}
+			]},
+			{"kind": "SCALAR", "name": "String"},
+			{"kind": "SCALAR", "name": "Int"}
+		],
+		"directives": [
+			{"name": "auth", "description": "Requires a \"role\".", "locations": ["FIELD_DEFINITION"], "args": [
+				{"name": "role", "description": "The role.", "type": {"kind": "SCALAR", "name": "String"}, "defaultValue": "\"admin\""}
+			]}
+		]
+	}`
+
+	schema, err := introspect.JSON([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sdl, err := introspect.SchemaToText(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: string(sdl)})
+	if err != nil {
+		t.Fatalf("failed to load schema: %v\n%s", err, sdl)
+	}
+
+	for _, typ := range schema.Types {
+		def := parsed.Types[*typ.Name]
+		if def == nil {
+			t.Fatalf("type %s not found", *typ.Name)
+		}
+
+		if !def.BuiltIn {
+			expectDescription(t, *typ.Name, typ.Description, def.Description)
+		}
+
+		for _, f := range typ.Fields {
+			field := def.Fields.ForName(f.Name)
+			name := *typ.Name + "." + f.Name
+
+			expectDescription(t, name, f.Description, field.Description)
+			expectDeprecation(t, name, f.IsDeprecated, f.DeprecationReason, field.Directives)
+			expectArguments(t, name, f.Args, field.Arguments)
+		}
+
+		for _, f := range typ.InputFields {
+			field := def.Fields.ForName(f.Name)
+			name := *typ.Name + "." + f.Name
+
+			expectDescription(t, name, f.Description, field.Description)
+			expectDeprecation(t, name, f.IsDeprecated, f.DeprecationReason, field.Directives)
+			expectDefaultValue(t, name, f.DefaultValue, field.DefaultValue)
+		}
+
+		for _, v := range typ.EnumValues {
+			value := def.EnumValues.ForName(v.Name)
+			name := *typ.Name + "." + v.Name
+
+			expectDescription(t, name, v.Description, value.Description)
+			expectDeprecation(t, name, v.IsDeprecated, v.DeprecationReason, value.Directives)
+		}
+	}
+
+	for _, d := range schema.Directives {
+		directive := parsed.Directives[d.Name]
+		if directive == nil {
+			t.Fatalf("directive %s not found", d.Name)
+		}
+
+		expectDescription(t, "@"+d.Name, d.Description, directive.Description)
+		for _, a := range d.Args {
+			arg := directive.Arguments.ForName(a.Name)
+			expectDescription(t, "@"+d.Name+"("+a.Name+":)", a.Description, arg.Description)
+			expectDefaultValue(t, "@"+d.Name+"("+a.Name+":)", a.DefaultValue, arg.DefaultValue)
+		}
+	}
+
+	// the schema loaded from the SDL re-introspects like the schema built
+	// directly from the introspection result
+	direct, err := introspect.ToAST(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := json.MarshalIndent(introspect.FromAST(direct), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := json.MarshalIndent(introspect.FromAST(parsed), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(expected) != string(got) {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func expectArguments(t *testing.T, name string, args []introspect.InputValue, defs ast.ArgumentDefinitionList) {
+	t.Helper()
+
+	for _, a := range args {
+		def := defs.ForName(a.Name)
+		if def == nil {
+			t.Fatalf("argument %s(%s:) not found", name, a.Name)
+		}
+
+		expectDescription(t, name+"("+a.Name+":)", a.Description, def.Description)
+		expectDefaultValue(t, name+"("+a.Name+":)", a.DefaultValue, def.DefaultValue)
+	}
+}
+
+func expectDescription(t *testing.T, name string, expected *string, got string) {
+	t.Helper()
+
+	if expected == nil {
+		expected = new(string)
+	}
+
+	if *expected != got {
+		t.Errorf("%s: expected description %q, got %q", name, *expected, got)
+	}
+}
+
+func expectDeprecation(t *testing.T, name string, isDeprecated bool, reason *string, directives ast.DirectiveList) {
+	t.Helper()
+
+	deprecated := directives.ForName("deprecated")
+	if isDeprecated != (deprecated != nil) {
+		t.Fatalf("%s: expected deprecated %t", name, isDeprecated)
+	}
+
+	if deprecated == nil || reason == nil {
+		return
+	}
+
+	arg := deprecated.Arguments.ForName("reason")
+	if arg == nil || arg.Value.Raw != *reason {
+		t.Errorf("%s: expected deprecation reason %q, got %v", name, *reason, arg)
+	}
+}
+
+func expectDefaultValue(t *testing.T, name string, expected *string, got *ast.Value) {
+	t.Helper()
+
+	if expected == nil {
+		if got != nil {
+			t.Errorf("%s: expected no default value, got %s", name, got)
+		}
+		return
+	}
+
+	if got == nil || got.String() != *expected {
+		t.Errorf("%s: expected default value %s, got %v", name, *expected, got)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "schema",
+			data: `{"queryType":{"name":"Query"},"types":[]}`,
+		},
+		{
+			name: "__schema",
+			data: `{"__schema":{"queryType":{"name":"Query"},"types":[]}}`,
+		},
+		{
+			name: "response",
+			data: `{"data":{"__schema":{"queryType":{"name":"Query"},"types":[]}}}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			schema, err := introspect.JSON([]byte(tc.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if schema.QueryType == nil || *schema.QueryType.Name != "Query" {
+				t.Fatalf("expected query type Query, got %v", schema.QueryType)
+			}
+		})
+	}
+
+	_, err := introspect.JSON([]byte(`{}`))
+	if err == nil {
+		t.Fatal("expected error for empty introspection result")
+	}
+}
+
+func TestFetch(t *testing.T) {
+	schema, err := os.ReadFile("./testdata/countries.trevorblades.com.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
 
-			// _, err = f.Write([]byte(txt))
-			// if err != nil {
-			// 	t.Fatal(err)
-			// }
+		fmt.Fprintf(w, `{"data":{"__schema":%s}}`, schema)
+	}))
+	defer server.Close()
+
+	_, err = introspect.URL(server.URL)
+	if err == nil {
+		t.Fatal("expected error without authorization header")
+	}
+
+	headers := http.Header{}
+	headers.Set("Authorization", "Bearer secret")
+
+	got, err := introspect.Fetch(context.Background(), server.URL, introspect.Options{
+		Headers: headers,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.QueryType == nil || *got.QueryType.Name != "Query" {
+		t.Fatalf("expected query type Query, got %v", got.QueryType)
+	}
+}
+
+func TestFetchFeatures(t *testing.T) {
+	var queries []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		queries = append(queries, body.Query)
+
+		if strings.Contains(body.Query, "IntrospectionFeatures") {
+			fmt.Fprint(w, `{"data":{
+				"schema": {"fields": [{"name": "description", "args": []}, {"name": "types", "args": []}]},
+				"type": {"fields": [{"name": "specifiedByURL", "args": []}, {"name": "inputFields", "args": [{"name": "includeDeprecated"}]}]},
+				"field": {"fields": [{"name": "args", "args": [{"name": "includeDeprecated"}]}]},
+				"directive": {"fields": [{"name": "isRepeatable", "args": []}, {"name": "args", "args": [{"name": "includeDeprecated"}]}]},
+				"inputValue": {"fields": [{"name": "isDeprecated", "args": []}, {"name": "deprecationReason", "args": []}]}
+			}}`)
+			return
+		}
+
+		// the server cuts the type references to the levels of the query
+		codesType := `{"kind": "NON_NULL", "ofType": {"kind": "LIST", "ofType": {"kind": "SCALAR", "name": "Code"}}}`
+		if strings.Count(body.Query[strings.Index(body.Query, "fragment TypeRef"):], "ofType") < 2 {
+			codesType = `{"kind": "NON_NULL", "ofType": {"kind": "LIST"}}`
+		}
+
+		fmt.Fprintf(w, `{"data":{"__schema":{
+			"description": "Countries.",
+			"queryType": {"name": "Query"},
+			"types": [
+				{"kind": "OBJECT", "name": "Query", "fields": [
+					{"name": "codes", "args": [], "type": %s}
+				]},
+				{"kind": "SCALAR", "name": "Code", "specifiedByURL": "https://www.iso.org/iso-3166-country-codes.html"}
+			],
+			"directives": [
+				{"name": "tag", "isRepeatable": true, "locations": ["OBJECT"], "args": []}
+			]
+		}}}`, codesType)
+	}))
+	defer server.Close()
+
+	schema, err := introspect.Fetch(context.Background(), server.URL, introspect.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(queries) != 2 {
+		t.Fatalf("expected the features query and the introspection query, got %d queries", len(queries))
+	}
+
+	for _, expected := range []string{"description\n    queryType", "specifiedByURL", "isRepeatable", "inputFields(includeDeprecated: true)", "args(includeDeprecated: true)", "defaultValue\n  isDeprecated\n  deprecationReason"} {
+		if !strings.Contains(queries[1], expected) {
+			t.Errorf("expected %q in the introspection query\n%s", expected, queries[1])
+		}
+	}
+
+	sdl, err := introspect.SchemaToText(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"\"\"\"\nCountries.\n\"\"\"\nschema {\n\tquery: Query\n}\n",
+		"scalar Code @specifiedBy(url: \"https://www.iso.org/iso-3166-country-codes.html\")\n",
+		"directive @tag repeatable on OBJECT\n",
+	} {
+		if !strings.Contains(string(sdl), expected) {
+			t.Errorf("expected %q in\n%s", expected, sdl)
+		}
+	}
+
+	// the features and a deeper type reference are sent as is, without
+	// detecting the features
+	queries = nil
+
+	_, err = introspect.Fetch(context.Background(), server.URL, introspect.Options{
+		TypeRefDepth: 2,
+		Features:     &introspect.Features{},
+	})
+	if err == nil || !strings.Contains(err.Error(), "Query.codes") {
+		t.Fatalf("expected error for the type of Query.codes deeper than the type reference depth, got %v", err)
+	}
+
+	if len(queries) != 1 || strings.Contains(queries[0], "specifiedByURL") {
+		t.Fatalf("expected a single introspection query without the features, got %q", queries)
+	}
+}
+
+func TestFromAST(t *testing.T) {
+	sdl, err := os.ReadFile("./testdata/countries.trevorblades.com.graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: string(sdl) + `
+		interface Node { code: ID! }
+		type Ocean implements Node { code: ID! }
+		type Sea implements Node { code: ID! }
+		union Water = Sea | Ocean
+	`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema := introspect.FromAST(parsed)
+
+	if schema.QueryType == nil || *schema.QueryType.Name != "Query" || schema.MutationType != nil {
+		t.Fatalf("expected query type Query and no mutation type, got %v and %v", schema.QueryType, schema.MutationType)
+	}
+
+	types := map[string]introspect.Type{}
+	for _, typ := range schema.Types {
+		types[*typ.Name] = typ
+	}
+
+	if query := types["Query"]; len(query.Fields) != 6 || query.Fields[0].Name != "continent" || query.Fields[0].Args[0].Type.String() != "ID!" {
+		t.Errorf("expected the fields of Query without __schema and __type, got %v", query.Fields)
+	}
+
+	if filter := types["CountryFilterInput"].InputFields; len(filter) != 3 || filter[0].Type.String() != "StringQueryOperatorInput" {
+		t.Errorf("expected the input fields of CountryFilterInput, got %v", filter)
+	}
+
+	for name, expected := range map[string]string{"Node": "Ocean Sea", "Water": "Sea Ocean"} {
+		var possibleTypes []string
+		for _, p := range types[name].PossibleTypes {
+			possibleTypes = append(possibleTypes, *p.Name)
+		}
+
+		if strings.Join(possibleTypes, " ") != expected {
+			t.Errorf("expected possible types %s of %s, got %v", expected, name, possibleTypes)
+		}
+	}
+
+	if types["__Schema"].Kind == nil || types["String"].Description == nil {
+		t.Error("expected the built-in types")
+	}
+
+	// the introspection result converts back to the same schema
+	doc, err := introspect.ToAST(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := json.Marshal(introspect.FromAST(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(expected) != string(got) {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestToASTErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{
+			name:     "cut type reference",
+			data:     `{"queryType": {"name": "Query"}, "types": [{"kind": "OBJECT", "name": "Query", "fields": [{"name": "codes", "args": [], "type": {"kind": "LIST"}}]}]}`,
+			expected: "type Query: field codes: incomplete type reference",
+		},
+		{
+			name:     "invalid default value",
+			data:     `{"queryType": {"name": "Query"}, "types": [{"kind": "OBJECT", "name": "Query", "fields": [{"name": "code", "args": [{"name": "first", "type": {"kind": "SCALAR", "name": "Int"}, "defaultValue": "1) { other"}], "type": {"kind": "SCALAR", "name": "ID"}}]}]}`,
+			expected: "type Query: field code: argument first: invalid default value 1) { other",
+		},
+		{
+			name:     "undefined type",
+			data:     `{"queryType": {"name": "Query"}, "types": [{"kind": "OBJECT", "name": "Query", "fields": [{"name": "country", "args": [], "type": {"kind": "OBJECT", "name": "Country"}}]}]}`,
+			expected: "Undefined type Country.",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			schema, err := introspect.JSON([]byte(tc.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = introspect.ToAST(schema)
+			if err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Fatalf("expected error %q, got %v", tc.expected, err)
+			}
 		})
 	}
 }
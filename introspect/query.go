@@ -1,102 +1,286 @@
 package introspect
 
-// introspectionQuery used for grahql schema introspection
-const introspectionQuery = `query IntrospectionQuery {
-  __schema {
-    queryType {
-      name
-    }
-    mutationType {
-      name
-    }
-    subscriptionType {
-      name
-    }
-    types {
-      ...FullType
-    }
-    directives {
-      name
-      description
-      locations
-      args {
-        ...InputValue
-      }
-    }
-  }
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultTypeRefDepth is the number of levels of the type references
+// requested by default, enough for types like [[String!]!]!
+const DefaultTypeRefDepth = 8
+
+// Features are the optional fields of the introspection schema, which were
+// added by later versions of the GraphQL spec and are not supported by every
+// server.
+type Features struct {
+	// SchemaDescription requests __Schema.description
+	SchemaDescription bool
+	// SpecifiedByURL requests __Type.specifiedByURL
+	SpecifiedByURL bool
+	// DirectiveIsRepeatable requests __Directive.isRepeatable
+	DirectiveIsRepeatable bool
+	// InputValueDeprecation requests the deprecated arguments and input
+	// fields, with __InputValue.isDeprecated and deprecationReason
+	InputValueDeprecation bool
 }
 
-fragment FullType on __Type {
-  kind
-  name
-  description
-  fields(includeDeprecated: true) {
-    name
-    description
-    args {
-      ...InputValue
-    }
-    type {
-      ...TypeRef
-    }
-    isDeprecated
-    deprecationReason
+// featuresQuery detects the features supported by the server from the
+// fields of the introspection types
+const featuresQuery = `query IntrospectionFeatures {
+  schema: __type(name: "__Schema") {
+    ...MetaType
   }
-  inputFields {
-    ...InputValue
+  type: __type(name: "__Type") {
+    ...MetaType
   }
-  interfaces {
-    ...TypeRef
+  field: __type(name: "__Field") {
+    ...MetaType
   }
-  enumValues(includeDeprecated: true) {
-    name
-    description
-    isDeprecated
-    deprecationReason
+  directive: __type(name: "__Directive") {
+    ...MetaType
   }
-  possibleTypes {
-    ...TypeRef
-  }
-}
-
-fragment InputValue on __InputValue {
-  name
-  description
-  type {
-    ...TypeRef
+  inputValue: __type(name: "__InputValue") {
+    ...MetaType
   }
-  defaultValue
 }
 
-fragment TypeRef on __Type {
-  kind
-  name
-  ofType {
-    kind
+fragment MetaType on __Type {
+  fields {
     name
-    ofType {
-      kind
+    args {
       name
-      ofType {
-        kind
-        name
-        ofType {
-          kind
-          name
-          ofType {
-            kind
-            name
-            ofType {
-              kind
-              name
-              ofType {
-                kind
-                name
-              }
-            }
-          }
-        }
-      }
     }
   }
 }`
+
+// metaType is an introspection type returned by featuresQuery, nil when the
+// server doesn't know the type
+type metaType struct {
+	Fields []struct {
+		Name string `json:"name"`
+		Args []struct {
+			Name string `json:"name"`
+		} `json:"args"`
+	} `json:"fields"`
+}
+
+// hasField returns true if the type has the field, and the field has the
+// arguments
+func (t *metaType) hasField(name string, args ...string) bool {
+	if t == nil {
+		return false
+	}
+
+	for _, f := range t.Fields {
+		if f.Name != name {
+			continue
+		}
+
+		for _, arg := range args {
+			found := false
+			for _, a := range f.Args {
+				if a.Name == arg {
+					found = true
+				}
+			}
+
+			if !found {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// featuresResult is the result of featuresQuery
+type featuresResult struct {
+	Schema     *metaType `json:"schema"`
+	Type       *metaType `json:"type"`
+	Field      *metaType `json:"field"`
+	Directive  *metaType `json:"directive"`
+	InputValue *metaType `json:"inputValue"`
+}
+
+func (r featuresResult) features() Features {
+	return Features{
+		SchemaDescription:     r.Schema.hasField("description"),
+		SpecifiedByURL:        r.Type.hasField("specifiedByURL"),
+		DirectiveIsRepeatable: r.Directive.hasField("isRepeatable"),
+		InputValueDeprecation: r.InputValue.hasField("isDeprecated") &&
+			r.InputValue.hasField("deprecationReason") &&
+			r.Type.hasField("inputFields", "includeDeprecated") &&
+			r.Field.hasField("args", "includeDeprecated") &&
+			r.Directive.hasField("args", "includeDeprecated"),
+	}
+}
+
+// introspectionQuery returns the query used for graphql schema introspection,
+// requesting the features and typeRefDepth levels of type references
+func introspectionQuery(features Features, typeRefDepth int) string {
+	var sb strings.Builder
+
+	line := func(indent int, s string) {
+		sb.WriteString(strings.Repeat("  ", indent) + s + "\n")
+	}
+
+	args := "args"
+	if features.InputValueDeprecation {
+		args = "args(includeDeprecated: true)"
+	}
+
+	line(0, "query IntrospectionQuery {")
+	line(1, "__schema {")
+	if features.SchemaDescription {
+		line(2, "description")
+	}
+	for _, root := range []string{"queryType", "mutationType", "subscriptionType"} {
+		line(2, root+" {")
+		line(3, "name")
+		line(2, "}")
+	}
+	line(2, "types {")
+	line(3, "...FullType")
+	line(2, "}")
+	line(2, "directives {")
+	line(3, "name")
+	line(3, "description")
+	if features.DirectiveIsRepeatable {
+		line(3, "isRepeatable")
+	}
+	line(3, "locations")
+	line(3, args+" {")
+	line(4, "...InputValue")
+	line(3, "}")
+	line(2, "}")
+	line(1, "}")
+	line(0, "}")
+	line(0, "")
+
+	line(0, "fragment FullType on __Type {")
+	line(1, "kind")
+	line(1, "name")
+	line(1, "description")
+	if features.SpecifiedByURL {
+		line(1, "specifiedByURL")
+	}
+	line(1, "fields(includeDeprecated: true) {")
+	line(2, "name")
+	line(2, "description")
+	line(2, args+" {")
+	line(3, "...InputValue")
+	line(2, "}")
+	line(2, "type {")
+	line(3, "...TypeRef")
+	line(2, "}")
+	line(2, "isDeprecated")
+	line(2, "deprecationReason")
+	line(1, "}")
+	if features.InputValueDeprecation {
+		line(1, "inputFields(includeDeprecated: true) {")
+	} else {
+		line(1, "inputFields {")
+	}
+	line(2, "...InputValue")
+	line(1, "}")
+	line(1, "interfaces {")
+	line(2, "...TypeRef")
+	line(1, "}")
+	line(1, "enumValues(includeDeprecated: true) {")
+	line(2, "name")
+	line(2, "description")
+	line(2, "isDeprecated")
+	line(2, "deprecationReason")
+	line(1, "}")
+	line(1, "possibleTypes {")
+	line(2, "...TypeRef")
+	line(1, "}")
+	line(0, "}")
+	line(0, "")
+
+	line(0, "fragment InputValue on __InputValue {")
+	line(1, "name")
+	line(1, "description")
+	line(1, "type {")
+	line(2, "...TypeRef")
+	line(1, "}")
+	line(1, "defaultValue")
+	if features.InputValueDeprecation {
+		line(1, "isDeprecated")
+		line(1, "deprecationReason")
+	}
+	line(0, "}")
+	line(0, "")
+
+	// kind
+	// name
+	// ofType {
+	//   kind
+	//   name
+	//   ...
+	// }
+	line(0, "fragment TypeRef on __Type {")
+	for level := 0; level < typeRefDepth; level++ {
+		if level > 0 {
+			line(level, "ofType {")
+		}
+		line(level+1, "kind")
+		line(level+1, "name")
+	}
+	for level := typeRefDepth - 1; level > 0; level-- {
+		line(level, "}")
+	}
+	sb.WriteString("}")
+
+	return sb.String()
+}
+
+// checkTypeRefs returns an error if a type reference of the schema was cut
+// because it is deeper than typeRefDepth
+func checkTypeRefs(schema *Schema, typeRefDepth int) error {
+	check := func(t *Type, path string) error {
+		for ref := t; ref != nil && ref.Kind != nil; ref = ref.OfType {
+			if (*ref.Kind == TypeKindList || *ref.Kind == TypeKindNonNull) && ref.OfType == nil {
+				return fmt.Errorf("the type of %s is deeper than the type reference depth %d", path, typeRefDepth)
+			}
+		}
+
+		return nil
+	}
+
+	for _, t := range schema.Types {
+		if t.Name == nil {
+			continue
+		}
+
+		for _, f := range t.Fields {
+			if err := check(&f.Type, *t.Name+"."+f.Name); err != nil {
+				return err
+			}
+
+			for _, a := range f.Args {
+				if err := check(&a.Type, fmt.Sprintf("%s.%s(%s:)", *t.Name, f.Name, a.Name)); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, f := range t.InputFields {
+			if err := check(&f.Type, *t.Name+"."+f.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, d := range schema.Directives {
+		for _, a := range d.Args {
+			if err := check(&a.Type, fmt.Sprintf("@%s(%s:)", d.Name, a.Name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -27,10 +27,12 @@ type Field struct {
 
 // InputValue represents a GraphQL input value.
 type InputValue struct {
-	Name         string  `json:"name"`
-	Description  *string `json:"description"`
-	Type         Type    `json:"type"`
-	DefaultValue *string `json:"defaultValue"`
+	Name              string  `json:"name"`
+	Description       *string `json:"description"`
+	Type              Type    `json:"type"`
+	DefaultValue      *string `json:"defaultValue"`
+	IsDeprecated      bool    `json:"isDeprecated,omitempty"`
+	DeprecationReason *string `json:"deprecationReason,omitempty"`
 }
 
 // EnumValue represents a GraphQL enum value.
@@ -43,35 +45,40 @@ type EnumValue struct {
 
 // InputField represents a GraphQL input field.
 type InputField struct {
-	Name         string  `json:"name"`
-	Description  *string `json:"description"`
-	Type         Type    `json:"type"`
-	DefaultValue *string `json:"defaultValue"`
+	Name              string  `json:"name"`
+	Description       *string `json:"description"`
+	Type              Type    `json:"type"`
+	DefaultValue      *string `json:"defaultValue"`
+	IsDeprecated      bool    `json:"isDeprecated,omitempty"`
+	DeprecationReason *string `json:"deprecationReason,omitempty"`
 }
 
 // Type represents a GraphQL type.
 type Type struct {
-	Kind          *TypeKind    `json:"kind"`
-	Name          *string      `json:"name"`
-	Description   *string      `json:"description"`
-	Fields        []Field      `json:"fields"`
-	InputFields   []InputField `json:"inputFields"`
-	Interfaces    []Type       `json:"interfaces"`
-	EnumValues    []EnumValue  `json:"enumValues"`
-	PossibleTypes []Type       `json:"possibleTypes"`
-	OfType        *Type        `json:"ofType,omitempty"`
+	Kind           *TypeKind    `json:"kind"`
+	Name           *string      `json:"name"`
+	Description    *string      `json:"description"`
+	SpecifiedByURL *string      `json:"specifiedByURL,omitempty"`
+	Fields         []Field      `json:"fields"`
+	InputFields    []InputField `json:"inputFields"`
+	Interfaces     []Type       `json:"interfaces"`
+	EnumValues     []EnumValue  `json:"enumValues"`
+	PossibleTypes  []Type       `json:"possibleTypes"`
+	OfType         *Type        `json:"ofType,omitempty"`
 }
 
 // Directive represents a GraphQL directive.
 type Directive struct {
-	Name        string       `json:"name"`
-	Description *string      `json:"description"`
-	Locations   []string     `json:"locations"`
-	Args        []InputValue `json:"args"`
+	Name         string       `json:"name"`
+	Description  *string      `json:"description"`
+	Locations    []string     `json:"locations"`
+	Args         []InputValue `json:"args"`
+	IsRepeatable bool         `json:"isRepeatable,omitempty"`
 }
 
 // Schema represents the GraphQL schema.
 type Schema struct {
+	Description      *string     `json:"description,omitempty"`
 	QueryType        *Type       `json:"queryType,omitempty"`
 	MutationType     *Type       `json:"mutationType,omitempty"`
 	SubscriptionType *Type       `json:"subscriptionType,omitempty"`
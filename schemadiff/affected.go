@@ -0,0 +1,179 @@
+package schemadiff
+
+import (
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Impact lists the breaking and dangerous changes used by an operation
+type Impact struct {
+	Operation *ast.OperationDefinition
+	Changes   []Change
+}
+
+// Affected returns the operations of the document which use the breaking or
+// dangerous changes. The operations are resolved against the old schema, the
+// one they were written for.
+func Affected(oldSchema *ast.Schema, doc *ast.QueryDocument, changes []Change) []Impact {
+	var impacts []Impact
+
+	for _, op := range doc.Operations {
+		refs := operationReferences(oldSchema, doc, op)
+
+		var used []Change
+		for _, change := range changes {
+			if change.Level != Safe && refs.uses(oldSchema, change) {
+				used = append(used, change)
+			}
+		}
+
+		if len(used) > 0 {
+			impacts = append(impacts, Impact{Operation: op, Changes: used})
+		}
+	}
+
+	return impacts
+}
+
+// references are the schema elements used by an operation: the types, the
+// fields as "Type.field" and the directives as "@name"
+type references struct {
+	types  map[string]bool
+	fields map[string]bool
+}
+
+// uses reports whether the change is on an element used by the operation.
+// The fields of objects and interfaces are matched precisely, the other
+// changes (input fields, enum values, union members...) by their type.
+func (r references) uses(schema *ast.Schema, change Change) bool {
+	if strings.HasPrefix(change.Type, "@") {
+		return r.types[change.Type]
+	}
+
+	def := schema.Types[change.Type]
+	if def != nil && (def.Kind == ast.Object || def.Kind == ast.Interface) && change.Field != "" && def.Fields.ForName(change.Field) != nil {
+		return r.fields[change.Type+"."+change.Field]
+	}
+
+	return r.types[change.Type]
+}
+
+func operationReferences(schema *ast.Schema, doc *ast.QueryDocument, op *ast.OperationDefinition) references {
+	w := referenceWalker{
+		schema:    schema,
+		doc:       doc,
+		refs:      references{types: map[string]bool{}, fields: map[string]bool{}},
+		fragments: map[string]bool{},
+	}
+
+	w.directives(op.Directives)
+
+	for _, v := range op.VariableDefinitions {
+		w.inputType(v.Type.Name())
+		w.directives(v.Directives)
+	}
+
+	var root *ast.Definition
+	switch op.Operation {
+	case ast.Mutation:
+		root = schema.Mutation
+	case ast.Subscription:
+		root = schema.Subscription
+	default:
+		root = schema.Query
+	}
+
+	if root != nil {
+		w.selectionSet(root, op.SelectionSet)
+	}
+
+	return w.refs
+}
+
+type referenceWalker struct {
+	schema    *ast.Schema
+	doc       *ast.QueryDocument
+	refs      references
+	fragments map[string]bool
+}
+
+func (w *referenceWalker) selectionSet(def *ast.Definition, selectionSet ast.SelectionSet) {
+	w.refs.types[def.Name] = true
+
+	for _, sel := range selectionSet {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			w.directives(sel.Directives)
+
+			if strings.HasPrefix(sel.Name, "__") {
+				continue
+			}
+
+			field := def.Fields.ForName(sel.Name)
+			if field == nil {
+				continue
+			}
+			w.refs.fields[def.Name+"."+sel.Name] = true
+
+			for _, arg := range sel.Arguments {
+				if argDef := field.Arguments.ForName(arg.Name); argDef != nil {
+					w.inputType(argDef.Type.Name())
+				}
+			}
+
+			if fieldDef := w.schema.Types[field.Type.Name()]; fieldDef != nil {
+				w.refs.types[fieldDef.Name] = true
+				if len(sel.SelectionSet) > 0 {
+					w.selectionSet(fieldDef, sel.SelectionSet)
+				}
+			}
+		case *ast.InlineFragment:
+			w.directives(sel.Directives)
+
+			fragmentDef := def
+			if sel.TypeCondition != "" {
+				fragmentDef = w.schema.Types[sel.TypeCondition]
+			}
+			if fragmentDef != nil {
+				w.selectionSet(fragmentDef, sel.SelectionSet)
+			}
+		case *ast.FragmentSpread:
+			w.directives(sel.Directives)
+
+			fragment := w.doc.Fragments.ForName(sel.Name)
+			if fragment == nil || w.fragments[sel.Name] {
+				continue
+			}
+			w.fragments[sel.Name] = true
+
+			w.directives(fragment.Directives)
+			if fragmentDef := w.schema.Types[fragment.TypeCondition]; fragmentDef != nil {
+				w.selectionSet(fragmentDef, fragment.SelectionSet)
+			}
+		}
+	}
+}
+
+// inputType adds the input type and the types of its fields
+func (w *referenceWalker) inputType(name string) {
+	if w.refs.types[name] {
+		return
+	}
+	w.refs.types[name] = true
+
+	def := w.schema.Types[name]
+	if def == nil || def.Kind != ast.InputObject {
+		return
+	}
+
+	for _, field := range def.Fields {
+		w.inputType(field.Type.Name())
+	}
+}
+
+func (w *referenceWalker) directives(directives ast.DirectiveList) {
+	for _, d := range directives {
+		w.refs.types["@"+d.Name] = true
+	}
+}
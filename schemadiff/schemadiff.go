@@ -0,0 +1,395 @@
+// Package schemadiff compares two versions of a GraphQL schema and reports the
+// operations affected by the changes.
+package schemadiff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Level classifies a change by its effect on the clients
+type Level int
+
+const (
+	// Safe changes don't affect the existing operations
+	Safe Level = iota
+	// Dangerous changes may change the behaviour of the existing operations,
+	// e.g. a new enum value the clients don't handle
+	Dangerous
+	// Breaking changes make existing operations invalid
+	Breaking
+)
+
+func (l Level) String() string {
+	switch l {
+	case Breaking:
+		return "BREAKING"
+	case Dangerous:
+		return "DANGEROUS"
+	default:
+		return "SAFE"
+	}
+}
+
+// Change is a difference between two schemas. Type, Field and Arg locate the
+// changed element, Field is also the enum value, union member, interface or
+// input field, and Type is "@name" for directives.
+type Change struct {
+	Level   Level
+	Message string
+	Type    string
+	Field   string
+	Arg     string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s", c.Level, c.Message)
+}
+
+// Compare returns the changes from the old schema to the new one, the types
+// and directives are compared in alphabetical order.
+func Compare(oldSchema, newSchema *ast.Schema) []Change {
+	var changes []Change
+
+	for _, name := range sortedKeys(oldSchema.Types) {
+		oldDef := oldSchema.Types[name]
+		if oldDef.BuiltIn {
+			continue
+		}
+
+		newDef := newSchema.Types[name]
+		if newDef == nil {
+			changes = append(changes, Change{Level: Breaking, Type: name, Message: fmt.Sprintf("type %s was removed", name)})
+			continue
+		}
+
+		if oldDef.Kind != newDef.Kind {
+			changes = append(changes, Change{Level: Breaking, Type: name, Message: fmt.Sprintf("type %s changed from %s to %s", name, kindName(oldDef.Kind), kindName(newDef.Kind))})
+			continue
+		}
+
+		switch oldDef.Kind {
+		case ast.Object, ast.Interface:
+			changes = append(changes, compareFields(oldDef, newDef)...)
+			changes = append(changes, compareInterfaces(oldDef, newDef)...)
+		case ast.InputObject:
+			changes = append(changes, compareInputFields(oldDef, newDef)...)
+		case ast.Enum:
+			changes = append(changes, compareEnumValues(oldDef, newDef)...)
+		case ast.Union:
+			changes = append(changes, compareUnionMembers(oldDef, newDef)...)
+		}
+	}
+
+	for _, name := range sortedKeys(newSchema.Types) {
+		if oldSchema.Types[name] == nil && !newSchema.Types[name].BuiltIn {
+			changes = append(changes, Change{Level: Safe, Type: name, Message: fmt.Sprintf("type %s was added", name)})
+		}
+	}
+
+	changes = append(changes, compareDirectives(oldSchema, newSchema)...)
+
+	return changes
+}
+
+func compareFields(oldDef, newDef *ast.Definition) []Change {
+	var changes []Change
+
+	for _, oldField := range oldDef.Fields {
+		if strings.HasPrefix(oldField.Name, "__") {
+			continue
+		}
+
+		coordinate := oldDef.Name + "." + oldField.Name
+
+		newField := newDef.Fields.ForName(oldField.Name)
+		if newField == nil {
+			changes = append(changes, Change{Level: Breaking, Type: oldDef.Name, Field: oldField.Name, Message: fmt.Sprintf("field %s was removed", coordinate)})
+			continue
+		}
+
+		if oldField.Type.String() != newField.Type.String() {
+			level := Breaking
+			if safeOutputChange(oldField.Type, newField.Type) {
+				level = Safe
+			}
+			changes = append(changes, Change{Level: level, Type: oldDef.Name, Field: oldField.Name, Message: fmt.Sprintf("field %s changed type from %s to %s", coordinate, oldField.Type, newField.Type)})
+		}
+
+		if isDeprecated(newField.Directives) && !isDeprecated(oldField.Directives) {
+			changes = append(changes, Change{Level: Safe, Type: oldDef.Name, Field: oldField.Name, Message: fmt.Sprintf("field %s was deprecated", coordinate)})
+		}
+
+		changes = append(changes, compareArguments(oldDef.Name, oldField, newField)...)
+	}
+
+	for _, newField := range newDef.Fields {
+		if oldDef.Fields.ForName(newField.Name) == nil && !strings.HasPrefix(newField.Name, "__") {
+			changes = append(changes, Change{Level: Safe, Type: oldDef.Name, Field: newField.Name, Message: fmt.Sprintf("field %s.%s was added", oldDef.Name, newField.Name)})
+		}
+	}
+
+	return changes
+}
+
+func compareArguments(typeName string, oldField, newField *ast.FieldDefinition) []Change {
+	var changes []Change
+
+	for _, oldArg := range oldField.Arguments {
+		coordinate := fmt.Sprintf("%s.%s(%s:)", typeName, oldField.Name, oldArg.Name)
+
+		newArg := newField.Arguments.ForName(oldArg.Name)
+		if newArg == nil {
+			changes = append(changes, Change{Level: Breaking, Type: typeName, Field: oldField.Name, Arg: oldArg.Name, Message: fmt.Sprintf("argument %s was removed", coordinate)})
+			continue
+		}
+
+		if oldArg.Type.String() != newArg.Type.String() {
+			level := Breaking
+			if safeInputChange(oldArg.Type, newArg.Type) {
+				level = Safe
+			}
+			changes = append(changes, Change{Level: level, Type: typeName, Field: oldField.Name, Arg: oldArg.Name, Message: fmt.Sprintf("argument %s changed type from %s to %s", coordinate, oldArg.Type, newArg.Type)})
+		}
+
+		if valueString(oldArg.DefaultValue) != valueString(newArg.DefaultValue) {
+			changes = append(changes, Change{Level: Dangerous, Type: typeName, Field: oldField.Name, Arg: oldArg.Name, Message: fmt.Sprintf("argument %s changed default value from %s to %s", coordinate, valueString(oldArg.DefaultValue), valueString(newArg.DefaultValue))})
+		}
+	}
+
+	for _, newArg := range newField.Arguments {
+		if oldField.Arguments.ForName(newArg.Name) != nil {
+			continue
+		}
+
+		coordinate := fmt.Sprintf("%s.%s(%s:)", typeName, oldField.Name, newArg.Name)
+		if newArg.Type.NonNull && newArg.DefaultValue == nil {
+			changes = append(changes, Change{Level: Breaking, Type: typeName, Field: oldField.Name, Arg: newArg.Name, Message: fmt.Sprintf("required argument %s was added", coordinate)})
+		} else {
+			changes = append(changes, Change{Level: Dangerous, Type: typeName, Field: oldField.Name, Arg: newArg.Name, Message: fmt.Sprintf("optional argument %s was added", coordinate)})
+		}
+	}
+
+	return changes
+}
+
+func compareInterfaces(oldDef, newDef *ast.Definition) []Change {
+	var changes []Change
+
+	for _, name := range oldDef.Interfaces {
+		if !contains(newDef.Interfaces, name) {
+			changes = append(changes, Change{Level: Breaking, Type: oldDef.Name, Field: name, Message: fmt.Sprintf("%s no longer implements interface %s", oldDef.Name, name)})
+		}
+	}
+
+	for _, name := range newDef.Interfaces {
+		if !contains(oldDef.Interfaces, name) {
+			changes = append(changes, Change{Level: Dangerous, Type: oldDef.Name, Field: name, Message: fmt.Sprintf("%s now implements interface %s", oldDef.Name, name)})
+		}
+	}
+
+	return changes
+}
+
+func compareInputFields(oldDef, newDef *ast.Definition) []Change {
+	var changes []Change
+
+	for _, oldField := range oldDef.Fields {
+		coordinate := oldDef.Name + "." + oldField.Name
+
+		newField := newDef.Fields.ForName(oldField.Name)
+		if newField == nil {
+			changes = append(changes, Change{Level: Breaking, Type: oldDef.Name, Field: oldField.Name, Message: fmt.Sprintf("input field %s was removed", coordinate)})
+			continue
+		}
+
+		if oldField.Type.String() != newField.Type.String() {
+			level := Breaking
+			if safeInputChange(oldField.Type, newField.Type) {
+				level = Safe
+			}
+			changes = append(changes, Change{Level: level, Type: oldDef.Name, Field: oldField.Name, Message: fmt.Sprintf("input field %s changed type from %s to %s", coordinate, oldField.Type, newField.Type)})
+		}
+
+		if valueString(oldField.DefaultValue) != valueString(newField.DefaultValue) {
+			changes = append(changes, Change{Level: Dangerous, Type: oldDef.Name, Field: oldField.Name, Message: fmt.Sprintf("input field %s changed default value from %s to %s", coordinate, valueString(oldField.DefaultValue), valueString(newField.DefaultValue))})
+		}
+	}
+
+	for _, newField := range newDef.Fields {
+		if oldDef.Fields.ForName(newField.Name) != nil {
+			continue
+		}
+
+		coordinate := oldDef.Name + "." + newField.Name
+		if newField.Type.NonNull && newField.DefaultValue == nil {
+			changes = append(changes, Change{Level: Breaking, Type: oldDef.Name, Field: newField.Name, Message: fmt.Sprintf("required input field %s was added", coordinate)})
+		} else {
+			changes = append(changes, Change{Level: Dangerous, Type: oldDef.Name, Field: newField.Name, Message: fmt.Sprintf("optional input field %s was added", coordinate)})
+		}
+	}
+
+	return changes
+}
+
+func compareEnumValues(oldDef, newDef *ast.Definition) []Change {
+	var changes []Change
+
+	for _, oldValue := range oldDef.EnumValues {
+		newValue := newDef.EnumValues.ForName(oldValue.Name)
+		if newValue == nil {
+			changes = append(changes, Change{Level: Breaking, Type: oldDef.Name, Field: oldValue.Name, Message: fmt.Sprintf("enum value %s.%s was removed", oldDef.Name, oldValue.Name)})
+			continue
+		}
+
+		if isDeprecated(newValue.Directives) && !isDeprecated(oldValue.Directives) {
+			changes = append(changes, Change{Level: Safe, Type: oldDef.Name, Field: oldValue.Name, Message: fmt.Sprintf("enum value %s.%s was deprecated", oldDef.Name, oldValue.Name)})
+		}
+	}
+
+	for _, newValue := range newDef.EnumValues {
+		if oldDef.EnumValues.ForName(newValue.Name) == nil {
+			changes = append(changes, Change{Level: Dangerous, Type: oldDef.Name, Field: newValue.Name, Message: fmt.Sprintf("enum value %s.%s was added", oldDef.Name, newValue.Name)})
+		}
+	}
+
+	return changes
+}
+
+func compareUnionMembers(oldDef, newDef *ast.Definition) []Change {
+	var changes []Change
+
+	for _, name := range oldDef.Types {
+		if !contains(newDef.Types, name) {
+			changes = append(changes, Change{Level: Breaking, Type: oldDef.Name, Field: name, Message: fmt.Sprintf("type %s was removed from union %s", name, oldDef.Name)})
+		}
+	}
+
+	for _, name := range newDef.Types {
+		if !contains(oldDef.Types, name) {
+			changes = append(changes, Change{Level: Dangerous, Type: oldDef.Name, Field: name, Message: fmt.Sprintf("type %s was added to union %s", name, oldDef.Name)})
+		}
+	}
+
+	return changes
+}
+
+func compareDirectives(oldSchema, newSchema *ast.Schema) []Change {
+	var changes []Change
+
+	for _, name := range sortedKeys(oldSchema.Directives) {
+		oldDirective := oldSchema.Directives[name]
+		if oldDirective.Position != nil && oldDirective.Position.Src != nil && oldDirective.Position.Src.BuiltIn {
+			continue
+		}
+
+		newDirective := newSchema.Directives[name]
+		if newDirective == nil {
+			changes = append(changes, Change{Level: Breaking, Type: "@" + name, Message: fmt.Sprintf("directive @%s was removed", name)})
+			continue
+		}
+
+		for _, oldArg := range oldDirective.Arguments {
+			newArg := newDirective.Arguments.ForName(oldArg.Name)
+			if newArg == nil {
+				changes = append(changes, Change{Level: Breaking, Type: "@" + name, Arg: oldArg.Name, Message: fmt.Sprintf("argument %s of directive @%s was removed", oldArg.Name, name)})
+			} else if oldArg.Type.String() != newArg.Type.String() && !safeInputChange(oldArg.Type, newArg.Type) {
+				changes = append(changes, Change{Level: Breaking, Type: "@" + name, Arg: oldArg.Name, Message: fmt.Sprintf("argument %s of directive @%s changed type from %s to %s", oldArg.Name, name, oldArg.Type, newArg.Type)})
+			}
+		}
+
+		for _, newArg := range newDirective.Arguments {
+			if oldDirective.Arguments.ForName(newArg.Name) == nil && newArg.Type.NonNull && newArg.DefaultValue == nil {
+				changes = append(changes, Change{Level: Breaking, Type: "@" + name, Arg: newArg.Name, Message: fmt.Sprintf("required argument %s was added to directive @%s", newArg.Name, name)})
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(newSchema.Directives) {
+		if oldSchema.Directives[name] == nil {
+			changes = append(changes, Change{Level: Safe, Type: "@" + name, Message: fmt.Sprintf("directive @%s was added", name)})
+		}
+	}
+
+	return changes
+}
+
+// safeOutputChange reports whether a field can change from the old type to
+// the new one without breaking the clients, which is the case when the new
+// type is stricter, e.g. String to String!
+func safeOutputChange(oldType, newType *ast.Type) bool {
+	switch {
+	case oldType.NonNull:
+		return newType.NonNull && safeOutputChange(nullable(oldType), nullable(newType))
+	case oldType.Elem != nil:
+		return (newType.Elem != nil && !newType.NonNull && safeOutputChange(oldType.Elem, newType.Elem)) ||
+			(newType.NonNull && safeOutputChange(oldType, nullable(newType)))
+	default:
+		return (newType.Elem == nil && !newType.NonNull && newType.NamedType == oldType.NamedType) ||
+			(newType.NonNull && safeOutputChange(oldType, nullable(newType)))
+	}
+}
+
+// safeInputChange reports whether an argument or input field can change from
+// the old type to the new one without breaking the clients, which is the case
+// when the new type is looser, e.g. String! to String
+func safeInputChange(oldType, newType *ast.Type) bool {
+	switch {
+	case oldType.NonNull:
+		if newType.NonNull {
+			return safeInputChange(nullable(oldType), nullable(newType))
+		}
+		return safeInputChange(nullable(oldType), newType)
+	case newType.NonNull:
+		return false
+	case oldType.Elem != nil:
+		return newType.Elem != nil && safeInputChange(oldType.Elem, newType.Elem)
+	default:
+		return newType.Elem == nil && newType.NamedType == oldType.NamedType
+	}
+}
+
+func nullable(t *ast.Type) *ast.Type {
+	c := *t
+	c.NonNull = false
+	return &c
+}
+
+func isDeprecated(directives ast.DirectiveList) bool {
+	return directives.ForName("deprecated") != nil
+}
+
+func valueString(v *ast.Value) string {
+	if v == nil {
+		return "none"
+	}
+
+	return v.String()
+}
+
+func kindName(kind ast.DefinitionKind) string {
+	return strings.ToLower(strings.ReplaceAll(string(kind), "_", " "))
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
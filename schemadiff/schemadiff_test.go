@@ -0,0 +1,193 @@
+package schemadiff_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stefanprifti/gqlclientgen/schemadiff"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+const oldSchema = `
+type Query {
+  country(code: ID!): Country
+  countries(filter: CountryFilter): [Country!]!
+  continents: [Continent!]!
+}
+
+type Country {
+  code: ID!
+  name: String!
+  capital: String
+  currency: String
+  continent: Continent!
+}
+
+type Continent {
+  code: ID!
+  name: String!
+}
+
+input CountryFilter {
+  code: String
+  continent: ContinentCode
+}
+
+enum ContinentCode {
+  AF
+  EU
+  AN
+}
+
+union Place = Country | Continent
+`
+
+const newSchema = `
+type Query {
+  country(code: ID!, lang: String!): Country
+  countries(filter: CountryFilter, first: Int): [Country!]!
+  continents: [Continent!]!
+  languages: [Language!]!
+}
+
+type Country {
+  code: ID!
+  name: String
+  capital: String!
+  continent: Continent!
+}
+
+type Continent {
+  code: ID!
+  name: String! @deprecated(reason: "use code")
+}
+
+type Language {
+  code: ID!
+}
+
+input CountryFilter {
+  code: String
+  continent: ContinentCode
+  name: String!
+}
+
+enum ContinentCode {
+  AF
+  EU
+  OC
+}
+
+union Place = Country | Continent | Language
+`
+
+func loadSchema(t *testing.T, sdl string) *ast.Schema {
+	t.Helper()
+
+	schema, err := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: sdl})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return schema
+}
+
+func TestCompare(t *testing.T) {
+	changes := schemadiff.Compare(loadSchema(t, oldSchema), loadSchema(t, newSchema))
+
+	var lines []string
+	for _, change := range changes {
+		lines = append(lines, change.String())
+	}
+
+	expected := []string{
+		"SAFE: field Continent.name was deprecated",
+		"DANGEROUS: enum value ContinentCode.OC was added",
+		"BREAKING: enum value ContinentCode.AN was removed",
+		"BREAKING: field Country.name changed type from String! to String",
+		"SAFE: field Country.capital changed type from String to String!",
+		"BREAKING: field Country.currency was removed",
+		"BREAKING: required input field CountryFilter.name was added",
+		"DANGEROUS: type Language was added to union Place",
+		"BREAKING: required argument Query.country(lang:) was added",
+		"DANGEROUS: optional argument Query.countries(first:) was added",
+		"SAFE: field Query.languages was added",
+		"SAFE: type Language was added",
+	}
+
+	// the changes of a type are in the order of its fields, the types are
+	// sorted, so only the content is compared
+	if strings.Join(sorted(lines), "\n") != strings.Join(sorted(expected), "\n") {
+		t.Fatalf("expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(lines, "\n"))
+	}
+}
+
+func TestAffected(t *testing.T) {
+	old := loadSchema(t, oldSchema)
+	changes := schemadiff.Compare(old, loadSchema(t, newSchema))
+
+	doc, err := parser.ParseQuery(&ast.Source{Name: "operations.graphql", Input: `
+		query Country($code: ID!) {
+		  country(code: $code) { ...CountryFields }
+		}
+
+		query Countries($filter: CountryFilter) {
+		  countries(filter: $filter) { code }
+		}
+
+		query Continents {
+		  continents { code name }
+		}
+
+		fragment CountryFields on Country {
+		  code
+		  capital
+		  currency
+		}
+	`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	impacts := schemadiff.Affected(old, doc, changes)
+
+	got := map[string][]string{}
+	for _, impact := range impacts {
+		for _, change := range impact.Changes {
+			got[impact.Operation.Name] = append(got[impact.Operation.Name], change.Message)
+		}
+	}
+
+	expected := map[string][]string{
+		"Country": {
+			"field Country.currency was removed",
+			"required argument Query.country(lang:) was added",
+		},
+		"Countries": {
+			"enum value ContinentCode.OC was added",
+			"enum value ContinentCode.AN was removed",
+			"required input field CountryFilter.name was added",
+			"optional argument Query.countries(first:) was added",
+		},
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+
+	for name, messages := range expected {
+		if strings.Join(sorted(got[name]), "\n") != strings.Join(sorted(messages), "\n") {
+			t.Errorf("operation %s: expected\n%s\ngot\n%s", name, strings.Join(messages, "\n"), strings.Join(got[name], "\n"))
+		}
+	}
+}
+
+func sorted(list []string) []string {
+	s := append([]string{}, list...)
+	sort.Strings(s)
+
+	return s
+}
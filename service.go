@@ -0,0 +1,447 @@
+package gqlclientgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/stefanprifti/gqlclientgen/gen"
+	"github.com/stefanprifti/gqlclientgen/introspect"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+	_ "github.com/vektah/gqlparser/v2/validator/rules"
+)
+
+type Operation struct {
+	FilePath    string
+	FileContent []byte
+	GQLTypes    []byte
+	Doc         *ast.QueryDocument
+}
+
+type Service struct {
+	Name    string
+	Package string
+
+	SchemaURL string
+	// Headers, BasicAuth and HTTPClient are used to introspect SchemaURL. The
+	// ${ENV_VAR} references of the headers and of the basic auth are expanded
+	// when the schema is fetched
+	Headers    http.Header
+	BasicAuth  BasicAuthConfig
+	HTTPClient *http.Client
+	// TypeRefDepth is the number of levels of the type references requested
+	// by the introspection query, zero for the default
+	TypeRefDepth int
+	// SchemaFile is the SDL or introspection result file the schema is
+	// loaded from instead of SchemaURL
+	SchemaFile string
+	// SchemaContent is the schema in GQL format
+	SchemaContent string
+	// SchemaDoc is the schema in AST format
+	SchemaDoc *ast.Schema
+	// SchemaJSON is the schema in JSON format
+	SchemaJSON []byte
+	// SchemaTTL is how long the introspection file written in ClientFolder is
+	// used as cache instead of fetching SchemaURL, zero means forever
+	SchemaTTL time.Duration
+	// Refresh fetches SchemaURL even if the cache is valid
+	Refresh bool
+	// SchemaCached reports whether the schema was loaded from the cache
+	SchemaCached bool
+	// SchemaOrder is the order of the types and fields of the introspected
+	// schema written in SchemaContent
+	SchemaOrder introspect.TextOptions
+
+	OperationsFolder string
+	// OperationsInclude and OperationsExclude are glob patterns, relative to
+	// OperationsFolder, selecting the operation files
+	OperationsInclude []string
+	OperationsExclude []string
+	OperationDocs     []Operation
+	// OperationsDoc contains the operations and fragments of all the operation files
+	OperationsDoc *ast.QueryDocument
+
+	ClientFolder string
+
+	// Nullable is the strategy used to generate nullable types
+	Nullable gen.NullableStrategy
+	// Naming is the strategy used to name the types of the nested selections
+	Naming gen.NamingStrategy
+	// Scalars maps a scalar name to the Go type used for it
+	Scalars map[string]string
+}
+
+type App struct {
+	Config   Config
+	Services []Service
+}
+
+func New(config Config) (*App, error) {
+	app := &App{}
+
+	err := app.setConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return app, nil
+}
+
+func (a *App) setConfig(config Config) error {
+	services := make([]Service, 0, len(config.Services))
+
+	for _, service := range config.Services {
+		headers := http.Header{}
+		for key, value := range service.Headers {
+			headers.Set(key, value)
+		}
+
+		httpClient, err := service.TLS.HTTPClient()
+		if err != nil {
+			return fmt.Errorf("service %s: %w", service.Name, err)
+		}
+
+		var schemaTTL time.Duration
+		if service.SchemaTTL != "" {
+			schemaTTL, err = time.ParseDuration(service.SchemaTTL)
+			if err != nil {
+				return fmt.Errorf("service %s: invalid schemaTTL: %w", service.Name, err)
+			}
+		}
+
+		schemaOrder, err := sortSchemaOption(service.SortSchema)
+		if err != nil {
+			return fmt.Errorf("service %s: %w", service.Name, err)
+		}
+
+		services = append(services, Service{
+			Name:              service.Name,
+			Package:           service.Package,
+			SchemaURL:         service.URL,
+			SchemaFile:        service.Schema,
+			SchemaTTL:         schemaTTL,
+			SchemaOrder:       schemaOrder,
+			Headers:           headers,
+			BasicAuth:         service.BasicAuth,
+			HTTPClient:        httpClient,
+			TypeRefDepth:      service.TypeRefDepth,
+			OperationsFolder:  service.Operations.Root,
+			OperationsInclude: service.Operations.Include,
+			OperationsExclude: service.Operations.Exclude,
+			ClientFolder:      service.Client.Root,
+			Nullable:          gen.NullableStrategy(service.Nullable),
+			Naming:            gen.NamingStrategy(service.Naming),
+			Scalars:           service.Scalars,
+		})
+	}
+
+	a.Services = services
+
+	return nil
+}
+
+// sortSchemaOption returns the order of the sortSchema config: types sorts the
+// types, all sorts the types and their fields
+func sortSchemaOption(value string) (introspect.TextOptions, error) {
+	switch value {
+	case "":
+		return introspect.TextOptions{}, nil
+	case "types":
+		return introspect.TextOptions{Sort: true}, nil
+	case "all":
+		return introspect.TextOptions{Sort: true, SortFields: true}, nil
+	default:
+		return introspect.TextOptions{}, fmt.Errorf("invalid sortSchema %q, expected types or all", value)
+	}
+}
+
+// ResolveSchema loads the schema of the service from the schema file, the
+// cache or the URL
+func (s *Service) ResolveSchema(ctx context.Context) error {
+	if s.SchemaFile != "" && isSDLFile(s.SchemaFile) {
+		return s.loadSDLSchema()
+	}
+
+	var schema *introspect.Schema
+	var err error
+
+	cacheFile, cached := s.cachedSchemaFile()
+	s.SchemaCached = cached
+
+	if s.SchemaFile != "" {
+		schema, err = introspect.File(s.SchemaFile)
+		if err != nil {
+			return fmt.Errorf("failed to load schema %s: %w", s.SchemaFile, err)
+		}
+	} else if cached {
+		schema, err = introspect.File(cacheFile)
+		if err != nil {
+			return fmt.Errorf("failed to load cached schema %s, run gqlclientgen schema pull to refresh it: %w", cacheFile, err)
+		}
+	} else {
+		// the environment variables are only needed to fetch the schema
+		headers, err := httpHeaders(s.Headers, s.BasicAuth)
+		if err != nil {
+			return err
+		}
+
+		schema, err = introspect.Fetch(ctx, s.SchemaURL, introspect.Options{
+			Headers:      headers,
+			HTTPClient:   s.HTTPClient,
+			TypeRefDepth: s.TypeRefDepth,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to fetch schema: %w", err)
+		}
+	}
+
+	schemaBytes, err := introspect.SchemaToTextWithOptions(schema, s.SchemaOrder)
+	if err != nil {
+		return fmt.Errorf("failed to convert schema to text: %w", err)
+	}
+
+	doc, err := introspect.ToAST(schema)
+	if err != nil {
+		return fmt.Errorf("failed to load schema: %w", err)
+	}
+
+	s.SchemaContent = string(schemaBytes)
+	s.SchemaDoc = doc
+	s.SchemaJSON, err = json.Marshal(schema)
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema: %w", err)
+	}
+
+	return nil
+}
+
+// cachedSchemaFile returns the introspection file written by a previous run,
+// if it can be used instead of fetching the schema
+func (s *Service) cachedSchemaFile() (string, bool) {
+	if s.SchemaFile != "" || s.Refresh {
+		return "", false
+	}
+
+	path := filepath.Join(s.ClientFolder, IntrospectionFileName)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+
+	if s.SchemaTTL > 0 && time.Since(info.ModTime()) > s.SchemaTTL {
+		return "", false
+	}
+
+	return path, true
+}
+
+// SchemaSource describes where the schema is resolved from
+func (s *Service) SchemaSource() string {
+	if s.SchemaFile != "" {
+		return s.SchemaFile
+	}
+
+	if path, ok := s.cachedSchemaFile(); ok {
+		return path + " (cached)"
+	}
+
+	return s.SchemaURL
+}
+
+// WrittenSchema loads the schema.graphql written in ClientFolder by a previous
+// run, it returns nil if there is none
+func (s *Service) WrittenSchema() (*ast.Schema, error) {
+	path := filepath.Join(s.ClientFolder, SchemaFileName)
+
+	body, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return gqlparser.LoadSchema(&ast.Source{Name: path, Input: string(body)})
+}
+
+// loadSDLSchema loads the schema from a GraphQL SDL file
+func (s *Service) loadSDLSchema() error {
+	body, err := os.ReadFile(s.SchemaFile)
+	if err != nil {
+		return fmt.Errorf("failed to read schema %s: %w", s.SchemaFile, err)
+	}
+
+	doc, err := gqlparser.LoadSchema(&ast.Source{Name: s.SchemaFile, Input: string(body)})
+	if err != nil {
+		return fmt.Errorf("failed to load schema: %w", err)
+	}
+
+	s.SchemaContent = string(body)
+	s.SchemaDoc = doc
+	s.SchemaJSON, err = json.Marshal(introspect.FromAST(doc))
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema: %w", err)
+	}
+
+	return nil
+}
+
+// isSDLFile reports whether the schema file is in GraphQL SDL format
+// instead of an introspection result
+func isSDLFile(path string) bool {
+	switch filepath.Ext(path) {
+	case ".graphql", ".graphqls", ".gql":
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Service) ResolveOperations() error {
+	errs, err := s.ParseOperations()
+	if err != nil {
+		return err
+	}
+
+	errs = append(errs, validator.Validate(s.SchemaDoc, s.OperationsDoc)...)
+	if len(errs) > 0 {
+		errs.sort()
+		return errs
+	}
+
+	// selections narrowed by fragments need __typename to be decoded
+	gen.InjectTypename(s.SchemaDoc, s.OperationsDoc)
+
+	return nil
+}
+
+// ParseOperations reads the operation files into OperationsDoc without
+// validating them against the schema. The syntax errors of all the files are
+// returned together, the error is set when a file can't be read.
+func (s *Service) ParseOperations() (OperationErrors, error) {
+	files, err := s.OperationFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	s.OperationsDoc = &ast.QueryDocument{}
+	s.OperationDocs = nil
+
+	// the errors of all the files are collected to report them at once
+	var errs OperationErrors
+
+	for _, file := range files {
+		body, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", file, err)
+		}
+
+		operationDoc, err := parser.ParseQuery(&ast.Source{Name: file, Input: string(body)})
+		if err != nil {
+			var gqlErr *gqlerror.Error
+			if !errors.As(err, &gqlErr) {
+				return nil, fmt.Errorf("failed to parse query %s: %w", file, err)
+			}
+
+			errs = append(errs, gqlErr)
+			continue
+		}
+
+		for _, op := range operationDoc.Operations {
+			if op.Name == "" {
+				errs = append(errs, gqlerror.ErrorPosf(op.Position, "anonymous operations are not supported, every operation needs a name to generate its method"))
+				continue
+			}
+
+			s.OperationsDoc.Operations = append(s.OperationsDoc.Operations, op)
+		}
+
+		s.OperationsDoc.Fragments = append(s.OperationsDoc.Fragments, operationDoc.Fragments...)
+
+		s.OperationDocs = append(s.OperationDocs, Operation{
+			FilePath:    file,
+			FileContent: body,
+			Doc:         operationDoc,
+		})
+	}
+
+	return errs, nil
+}
+
+// defaultOperationsInclude selects the operation files when the service has
+// no include patterns
+var defaultOperationsInclude = []string{"**/*.graphql", "**/*.gql"}
+
+// OperationFiles walks OperationsFolder and returns the operation files
+// matching the include patterns and none of the exclude patterns
+func (s *Service) OperationFiles() ([]string, error) {
+	include := s.OperationsInclude
+	if len(include) == 0 {
+		include = defaultOperationsInclude
+	}
+
+	var files []string
+
+	err := filepath.WalkDir(s.OperationsFolder, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.OperationsFolder, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		included, err := matchAnyGlob(include, rel)
+		if err != nil || !included {
+			return err
+		}
+
+		excluded, err := matchAnyGlob(s.OperationsExclude, rel)
+		if err != nil || excluded {
+			return err
+		}
+
+		files = append(files, path)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read folder %s: %w", s.OperationsFolder, err)
+	}
+
+	return files, nil
+}
+
+// operationQuery returns the query sent for the operation, which is the
+// operation followed by the fragments it uses
+func (s *Service) operationQuery(op *ast.OperationDefinition) string {
+	// the document is formatted instead of using the file content because
+	// the operations may have been changed, e.g. by adding __typename
+	var buf bytes.Buffer
+	formatter.NewFormatter(&buf, formatter.WithIndent("  ")).FormatQueryDocument(&ast.QueryDocument{
+		Operations: ast.OperationList{op},
+		Fragments:  gen.OperationFragments(s.OperationsDoc, op),
+	})
+
+	return buf.String()
+}
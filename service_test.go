@@ -0,0 +1,235 @@
+package gqlclientgen
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sampleProject is the sample project of the gqlclientgen command
+const sampleProject = "cmd/gqlclientgen/testdata"
+
+func TestResolveOperations(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"heroes.graphql": `
+			query HeroName { hero { ...CharacterName } }
+			query HeroId { hero { id } }
+		`,
+		"fragments/character.graphql": `fragment CharacterName on Character { name }`,
+		"search/search.graphql":       `query Search { search(text: "r2") { __typename } }`,
+		"search/draft.graphql":        `query Draft { unknownField }`,
+		"readme.md":                   `not an operation`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := Service{
+		SchemaFile:        filepath.Join(sampleProject, "starwars.graphql"),
+		OperationsFolder:  dir,
+		OperationsExclude: []string{"**/draft.graphql"},
+	}
+	if err := s.ResolveSchema(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ResolveOperations(); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, op := range s.OperationsDoc.Operations {
+		names = append(names, op.Name)
+	}
+	if strings.Join(names, ",") != "HeroName,HeroId,Search" {
+		t.Fatalf("unexpected operations %v", names)
+	}
+
+	// the query of an operation has only the operation and the fragments it uses
+	query := s.operationQuery(s.OperationsDoc.Operations[0])
+	if !strings.Contains(query, "fragment CharacterName") || strings.Contains(query, "HeroId") {
+		t.Fatalf("unexpected query %s", query)
+	}
+
+	query = s.operationQuery(s.OperationsDoc.Operations[1])
+	if strings.Contains(query, "fragment CharacterName") || strings.Contains(query, "HeroName") {
+		t.Fatalf("unexpected query %s", query)
+	}
+}
+
+func TestResolveOperationsErrors(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"hero.graphql":   "query Hero {\n  hero {\n    foo\n  }\n}\n",
+		"search.graphql": "query Search {\n  search(text: \"r2\") {\n",
+		"review.graphql": "query {\n  hero { id }\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := Service{
+		SchemaFile:       filepath.Join(sampleProject, "starwars.graphql"),
+		OperationsFolder: dir,
+	}
+	if err := s.ResolveSchema(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	err := s.ResolveOperations()
+
+	var errs OperationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected OperationErrors, got %v", err)
+	}
+
+	expected := strings.Join([]string{
+		filepath.Join(dir, "hero.graphql") + `:3:5: Cannot query field "foo" on type "Character".`,
+		filepath.Join(dir, "review.graphql") + `:1:1: anonymous operations are not supported, every operation needs a name to generate its method`,
+		filepath.Join(dir, "search.graphql") + `:3:1: Expected Name, found <EOF>`,
+	}, "\n")
+	if err.Error() != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, err)
+	}
+}
+
+func TestResolveSchemaCache(t *testing.T) {
+	schema, err := os.ReadFile(filepath.Join(sampleProject, "pkg", "countries", IntrospectionFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// requests counts the introspection queries, not the queries detecting
+	// the introspection features
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if bytes.Contains(body, []byte("query IntrospectionQuery")) {
+			requests++
+		}
+		fmt.Fprintf(w, `{"data":{"__schema":%s}}`, schema)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	resolve := func(s Service) Service {
+		t.Helper()
+		s.SchemaURL = server.URL
+		s.ClientFolder = dir
+		if err := s.ResolveSchema(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.GenerateIntrospectionFile(); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	// the first run fetches the schema and writes the cache
+	if s := resolve(Service{}); s.SchemaCached || requests != 1 {
+		t.Fatalf("expected the schema to be fetched, cached %v, %d requests", s.SchemaCached, requests)
+	}
+
+	if s := resolve(Service{}); !s.SchemaCached || requests != 1 || s.SchemaDoc.Types["Country"] == nil {
+		t.Fatalf("expected the cached schema, cached %v, %d requests", s.SchemaCached, requests)
+	}
+
+	if s := resolve(Service{Refresh: true}); s.SchemaCached || requests != 2 {
+		t.Fatalf("expected the schema to be refreshed, cached %v, %d requests", s.SchemaCached, requests)
+	}
+
+	// an expired cache is fetched again
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, IntrospectionFileName), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := resolve(Service{SchemaTTL: time.Hour}); s.SchemaCached || requests != 3 {
+		t.Fatalf("expected the expired schema to be fetched, cached %v, %d requests", s.SchemaCached, requests)
+	}
+
+	if s := resolve(Service{SchemaTTL: time.Hour}); !s.SchemaCached || requests != 3 {
+		t.Fatalf("expected the cached schema, cached %v, %d requests", s.SchemaCached, requests)
+	}
+}
+
+func TestResolveSchemaHeaders(t *testing.T) {
+	schema, err := os.ReadFile(filepath.Join(sampleProject, "pkg", "countries", IntrospectionFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		fmt.Fprintf(w, `{"data":{"__schema":%s}}`, schema)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, IntrospectionFileName), schema, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	configFile := filepath.Join(dir, ConfigFileName)
+	config := fmt.Sprintf(`
+version: 1
+services:
+  - name: countries
+    url: %s
+    headers:
+      Authorization: Bearer ${GQLCLIENTGEN_UNSET_TOKEN}
+    client:
+      root: %s
+`, server.URL, dir)
+	if err := os.WriteFile(configFile, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the variables are not needed until the schema is fetched
+	app, err := New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := app.Services[0]
+	if err := s.ResolveSchema(context.Background()); err != nil || !s.SchemaCached {
+		t.Fatalf("expected the cached schema, cached %v: %v", s.SchemaCached, err)
+	}
+
+	s.Refresh = true
+	err = s.ResolveSchema(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "GQLCLIENTGEN_UNSET_TOKEN is not set") {
+		t.Fatalf("expected the unset variable error, got %v", err)
+	}
+
+	t.Setenv("GQLCLIENTGEN_UNSET_TOKEN", "token")
+	if err := s.ResolveSchema(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if authorization != "Bearer token" {
+		t.Fatalf("expected the expanded header, got %q", authorization)
+	}
+}